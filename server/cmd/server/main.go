@@ -1,12 +1,17 @@
 package main
 
 import (
+    "context"
     "log"
     "net/http"
     "os"
     "time"
 
     "optimal-rion/server/controller"
+    "optimal-rion/server/controller/bus"
+    "optimal-rion/server/controller/calendar"
+    "optimal-rion/server/controller/history"
+    "optimal-rion/server/controller/route"
     "optimal-rion/server/routes"
 )
 
@@ -23,15 +28,81 @@ func withCORS(next http.Handler) http.Handler {
     })
 }
 
+// loadRoutes reads ROUTES_FILE when set. Otherwise it serves the built-in
+// Niiza route, configured by BUS_TIMETABLE_FILE, STATION_GROUPS_FILE and
+// the <NAME>_BIKE_PRIMARY / <NAME>_BIKE_SECONDARY env overrides.
+func loadRoutes(cal *calendar.Calendar) (*route.Registry, error) {
+    if path := os.Getenv("ROUTES_FILE"); path != "" {
+        return route.Load(path, cal)
+    }
+    timetable, err := bus.Load(os.Getenv("BUS_TIMETABLE_FILE"))
+    if err != nil {
+        return nil, err
+    }
+    timetable.UseCalendar(cal)
+    groups, err := controller.LoadStationGroups(os.Getenv("STATION_GROUPS_FILE"))
+    if err != nil {
+        return nil, err
+    }
+    return route.Default(groups, timetable), nil
+}
+
+// durationEnv reads a time.Duration env var, falling back to def.
+func durationEnv(key string, def time.Duration) time.Duration {
+    v := os.Getenv(key)
+    if v == "" {
+        return def
+    }
+    d, err := time.ParseDuration(v)
+    if err != nil || d <= 0 {
+        log.Fatalf("%s: invalid duration %q", key, v)
+    }
+    return d
+}
+
+// startHistory opens the bike availability history in HISTORY_DIR and
+// starts polling every HISTORY_INTERVAL, keeping HISTORY_RETENTION of
+// data. It returns nil when HISTORY_DIR is unset.
+func startHistory(fetch *controller.FetchController, reg *route.Registry) *history.Store {
+    dir := os.Getenv("HISTORY_DIR")
+    if dir == "" {
+        return nil
+    }
+    store, err := history.Open(dir, durationEnv("HISTORY_RETENTION", 14*24*time.Hour), time.Now())
+    if err != nil {
+        log.Fatalf("history: %v", err)
+    }
+    rec := &history.Recorder{
+        Store:    store,
+        Fetch:    fetch,
+        Routes:   reg,
+        Interval: durationEnv("HISTORY_INTERVAL", 5*time.Minute),
+        Timeout:  30 * time.Second,
+    }
+    go rec.Run(context.Background())
+    return store
+}
+
 func main() {
     // Construct shared fetch controller
     fetch := controller.NewFetchController()
-    mux := routes.New(fetch)
 
-    // Optionally warn if API key is not set
-    if os.Getenv("OPENWEATHER_API_KEY") == "" {
-        log.Println("[warn] OPENWEATHER_API_KEY not set; upstream calls will fail")
+    // Public holidays are always applied; ACADEMIC_CALENDAR_FILE adds
+    // university vacations, exams and special-schedule days
+    cal, err := calendar.Load(os.Getenv("ACADEMIC_CALENDAR_FILE"))
+    if err != nil {
+        log.Fatalf("academic calendar: %v", err)
     }
+    reg, err := loadRoutes(cal)
+    if err != nil {
+        log.Fatalf("routes: %v", err)
+    }
+    hist := startHistory(fetch, reg)
+    mux := routes.New(fetch, reg, hist)
+
+    // WEATHER_PROVIDER picks the weather source; without it, Open-Meteo
+    // stands in when no OpenWeather key is configured
+    log.Printf("weather provider: %s", fetch.Weather.ID())
 
     srv := &http.Server{
         Addr:         ":8080",
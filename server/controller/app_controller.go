@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"context"
+	"sync"
+)
+
+// AppInputs collects every upstream source the app handlers need, with a
+// separate error per source so one failure doesn't hide the others.
+type AppInputs struct {
+	Weather    WeatherDTO
+	WeatherErr error
+	Bike       BikeTotalsDTO
+	BikeErr    error
+}
+
+// FetchAppInputs fetches weather and bike data in parallel under ctx's
+// deadline, so latency is that of the slowest source rather than the sum.
+func FetchAppInputs(ctx context.Context, f *FetchController, groups StationGroups, lat, lon float64, units Units, lang string) AppInputs {
+	var in AppInputs
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		in.Weather, in.WeatherErr = FetchWeather(ctx, f, lat, lon, units, lang)
+	}()
+	go func() {
+		defer wg.Done()
+		in.Bike, in.BikeErr = FetchBikeTotals(ctx, f, groups)
+	}()
+	wg.Wait()
+	return in
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const sourceDelay = 150 * time.Millisecond
+
+// slowUpstream serves OneCall and both GBFS feeds, each after sourceDelay.
+// The GBFS discovery file is served immediately, as it is cached for long
+// stretches in production.
+func slowUpstream(tb testing.TB) *FetchController {
+	tb.Helper()
+	oneCall := minimalOneCall(1_000, 20, 50, nil)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v4/gbfs/hellocycling/gbfs.json" {
+			_, _ = w.Write([]byte(helloDiscovery))
+			return
+		}
+		time.Sleep(sourceDelay)
+		switch r.URL.Path {
+		case "/data/3.0/onecall":
+			_, _ = w.Write(oneCall)
+		case "/api/v4/gbfs/hellocycling/station_information.json":
+			_, _ = w.Write([]byte(`{"ttl": 60, "data": {"stations": [{"station_id": "6504", "capacity": 10}]}}`))
+		case "/api/v4/gbfs/hellocycling/station_status.json":
+			_, _ = w.Write([]byte(`{"ttl": 60, "data": {"stations": [{"station_id": "6504", "num_bikes_available": 4}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	tb.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	fc := NewFetchController()
+	fc.Client = &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &rewriteTransport{base: u, rt: http.DefaultTransport},
+	}
+	fc.Weather = &OpenWeatherProvider{APIKey: "testkey"}
+	return fc
+}
+
+func TestFetchAppInputs_LatencyIsSlowestSource(t *testing.T) {
+	fc := slowUpstream(t)
+
+	start := time.Now()
+	in := FetchAppInputs(context.Background(), fc, DefaultStationGroups, 35.0, 139.0, MetricUnits, "ja")
+	elapsed := time.Since(start)
+
+	if in.WeatherErr != nil || in.BikeErr != nil {
+		t.Fatalf("unexpected errors: weather=%v bike=%v", in.WeatherErr, in.BikeErr)
+	}
+	if st := in.Bike.Group(GroupStation); st.Rentable != 4 || st.Returnable != 6 {
+		t.Fatalf("unexpected station totals: %+v", in.Bike.Group(GroupStation))
+	}
+	// Three sources sequentially would take 3*sourceDelay.
+	if elapsed >= 2*sourceDelay {
+		t.Fatalf("sources were not fetched in parallel: took %v", elapsed)
+	}
+}
+
+func TestFetchAppInputs_SharedDeadline(t *testing.T) {
+	fc := slowUpstream(t)
+	fc.Retry = RetryPolicy{MaxAttempts: 1}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sourceDelay/3)
+	defer cancel()
+	start := time.Now()
+	in := FetchAppInputs(ctx, fc, DefaultStationGroups, 35.0, 139.0, MetricUnits, "ja")
+	if in.WeatherErr == nil || in.BikeErr == nil {
+		t.Fatalf("expected both sources to hit the deadline: weather=%v bike=%v", in.WeatherErr, in.BikeErr)
+	}
+	if elapsed := time.Since(start); elapsed >= sourceDelay {
+		t.Fatalf("deadline not honoured: took %v", elapsed)
+	}
+}
+
+func BenchmarkFetchAppInputs(b *testing.B) {
+	fc := slowUpstream(b)
+	for i := 0; i < b.N; i++ {
+		// A fresh cache each round so every source is really fetched.
+		fc.cache = newResponseCache()
+		FetchAppInputs(context.Background(), fc, DefaultStationGroups, 35.0, 139.0, MetricUnits, "ja")
+	}
+}
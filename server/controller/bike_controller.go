@@ -2,108 +2,238 @@ package controller
 
 import (
     "context"
+    "fmt"
+    "log"
+    "os"
+    "sync"
+    "time"
 )
 
-const (
-    helloInfoURL   = "https://api-public.odpt.org/api/v4/gbfs/hellocycling/station_information.json"
-    helloStatusURL = "https://api-public.odpt.org/api/v4/gbfs/hellocycling/station_status.json"
-)
+// defaultStationStaleAfter is how long a port may go without reporting
+// before it is flagged stale; BIKE_STATION_STALE_AFTER overrides it.
+const defaultStationStaleAfter = 30 * time.Minute
+
+func stationStaleAfter() time.Duration {
+    if v := os.Getenv("BIKE_STATION_STALE_AFTER"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil && d > 0 {
+            return d
+        }
+        log.Printf("[warn] ignoring invalid BIKE_STATION_STALE_AFTER %q", v)
+    }
+    return defaultStationStaleAfter
+}
 
-// GBFS Information payload (partial)
-type helloInfo struct {
-    Data struct {
-        Stations []struct {
-            StationID string `json:"station_id"`
-            Capacity  int    `json:"capacity"`
-        } `json:"stations"`
-    } `json:"data"`
+// StationDetail is one port's share of its group's totals.
+type StationDetail struct {
+    ID         string  `json:"id"`
+    Operator   string  `json:"operator"`
+    Name       string  `json:"name,omitempty"`
+    Lat        float64 `json:"lat,omitempty"`
+    Lon        float64 `json:"lon,omitempty"`
+    Rentable   int     `json:"rentable"`
+    Returnable int     `json:"returnable"`
+    Capacity   int     `json:"capacity,omitempty"`
+    BikesDisabled int `json:"bikesDisabled,omitempty"`
+    // Electric counts rentable e-bikes; Charged and ChargeUnknown split
+    // the rentable bikes into those with enough range (or no battery) and
+    // those the feeds could not classify.
+    Electric      int `json:"electric,omitempty"`
+    Charged       int `json:"charged"`
+    ChargeUnknown int `json:"chargeUnknown,omitempty"`
+    // Ports that are not installed, renting or returning contribute zero
+    // rentable or returnable bikes regardless of their counts.
+    Installed bool `json:"installed"`
+    Renting   bool `json:"renting"`
+    Returning bool `json:"returning"`
+    // LastReported is when the port last reported its status; nil if the
+    // port is missing from station_status.
+    LastReported *time.Time `json:"lastReported,omitempty"`
+    // Missing marks ports absent from station_status; Stale marks ports
+    // that have not reported within the stale threshold.
+    Missing bool `json:"missing,omitempty"`
+    Stale   bool `json:"stale,omitempty"`
+    // Secondary marks ports from the group's fallback list.
+    Secondary bool `json:"secondary,omitempty"`
 }
 
-// GBFS Status payload (partial)
-type helloStatus struct {
-    Data struct {
-        Stations []struct {
-            StationID          string `json:"station_id"`
-            NumBikesAvailable  int    `json:"num_bikes_available"`
-            NumDocksAvailable  *int   `json:"num_docks_available,omitempty"`
-        } `json:"stations"`
-    } `json:"data"`
+// OperatorTotals is one operator's share of a group.
+type OperatorTotals struct {
+    Rentable   int `json:"rentable"`
+    Returnable int `json:"returnable"`
 }
 
 type groupTotals struct {
     Rentable  int `json:"rentable"`
     Returnable int `json:"returnable"`
+    // Charged and ChargeUnknown split Rentable as in StationDetail.
+    Charged       int `json:"charged"`
+    ChargeUnknown int `json:"chargeUnknown,omitempty"`
+    // Stations breaks the sums down per port, in configured order.
+    Stations []StationDetail `json:"stations,omitempty"`
+    // Operators breaks the sums down by bike-share operator.
+    Operators map[string]OperatorTotals `json:"operators,omitempty"`
+    // MissingStations and StaleStations list the references of ports whose
+    // counts are absent or out of date.
+    MissingStations []string `json:"missingStations,omitempty"`
+    StaleStations   []string `json:"staleStations,omitempty"`
+    // Fallback sums the secondary stations, if the group has any.
+    Fallback *groupTotals `json:"fallback,omitempty"`
+}
+
+// BikeOperatorDTO reports how one operator's feeds fared. Err is set when
+// the operator could not be fetched; its stations then count as missing.
+type BikeOperatorDTO struct {
+    ID      string `json:"id"`
+    Name    string `json:"name"`
+    Err     error  `json:"-"`
+    DataAge `json:"-"`
 }
 
-// BikeTotalsDTO aggregates totals for off-campus station group and campus group (primary IDs only).
+// BikeTotalsDTO aggregates totals per configured station group.
+// DataAge refers to station_status, which drives the counts: the oldest
+// across operators, stale if any is.
 type BikeTotalsDTO struct {
-    Station groupTotals `json:"station"`
-    Campus  groupTotals `json:"campus"`
+    Groups    map[string]groupTotals `json:"groups"`
+    Operators []BikeOperatorDTO      `json:"operators"`
+    DataAge   `json:"-"`
 }
 
-// Primary station ID groups (public IDs; safe to embed)
-var (
-    // Campus bike primary IDs
-    campusPrimaryIDs = []string{"14743", "5770", "5769", "3151", "4223", "3150", "16774", "5778", "5776", "6832"}
-    // Nearest station (Shonandai/Niiza etc.) primary IDs
-    stationPrimaryIDs = []string{"6504", "6503", "7060", "6502", "23069"}
-)
+// Group returns the totals of a named group (zero if unknown).
+func (b BikeTotalsDTO) Group(name string) groupTotals {
+    return b.Groups[name]
+}
+
+// FetchBikeTotals fetches every operator referenced by groups in parallel
+// and computes totals for the configured station groups. It fails only
+// when no operator could be fetched.
+func FetchBikeTotals(ctx context.Context, f *FetchController, groups StationGroups) (BikeTotalsDTO, error) {
+    return fetchBikeTotals(ctx, f, bikeProviders(), groups)
+}
 
-// FetchBikeTotals fetches Hello Cycling GBFS and computes totals for the primary station groups.
-// It reads CAMPUS_BIKE_PRIMARY and STATION_BIKE_PRIMARY from the environment.
-func FetchBikeTotals(ctx context.Context, f *FetchController) (BikeTotalsDTO, error) {
+func fetchBikeTotals(ctx context.Context, f *FetchController, providers BikeProviders, groups StationGroups) (BikeTotalsDTO, error) {
     var out BikeTotalsDTO
 
-    var info helloInfo
-    if err := f.GetJSON(ctx, helloInfoURL, nil, &info); err != nil {
-        return out, err
-    }
-    var status helloStatus
-    if err := f.GetJSON(ctx, helloStatusURL, nil, &status); err != nil {
-        return out, err
+    ops := operatorsOf(groups)
+    snaps := make([]BikeSnapshot, len(ops))
+    out.Operators = make([]BikeOperatorDTO, len(ops))
+    var wg sync.WaitGroup
+    for i, op := range ops {
+        out.Operators[i] = BikeOperatorDTO{ID: op, Name: op}
+        p, ok := providers[op]
+        if !ok {
+            out.Operators[i].Err = fmt.Errorf("unknown bike operator %q", op)
+            continue
+        }
+        out.Operators[i].Name = p.Name()
+        wg.Add(1)
+        go func(i int, p BikeProvider) {
+            defer wg.Done()
+            snaps[i], out.Operators[i].Err = p.Snapshot(ctx, f)
+        }(i, p)
     }
+    wg.Wait()
 
-    capByID := map[string]int{}
-    for _, s := range info.Data.Stations {
-        capByID[s.StationID] = s.Capacity
+    var firstErr error
+    snapByOp := map[string]BikeSnapshot{}
+    for i, o := range out.Operators {
+        if o.Err != nil {
+            log.Printf("[warn] bike operator %s unavailable: %v", o.ID, o.Err)
+            if firstErr == nil {
+                firstErr = fmt.Errorf("%s: %w", o.ID, o.Err)
+            }
+            continue
+        }
+        out.Operators[i].DataAge = snaps[i].DataAge
+        snapByOp[o.ID] = snaps[i]
+        if len(snapByOp) == 1 || snaps[i].FetchedAt.Before(out.FetchedAt) {
+            out.FetchedAt, out.AgeSeconds = snaps[i].FetchedAt, snaps[i].AgeSeconds
+        }
+        out.Stale = out.Stale || snaps[i].Stale
     }
-    type st struct{ bikes int; docks *int }
-    stByID := map[string]st{}
-    for _, s := range status.Data.Stations {
-        stByID[s.StationID] = st{bikes: s.NumBikesAvailable, docks: s.NumDocksAvailable}
+    if len(snapByOp) == 0 && firstErr != nil {
+        return out, firstErr
     }
 
-    rentable := func(id string) int {
-        s, ok := stByID[id]
-        if !ok {
+    rentable := func(s BikeStation) int {
+        if !s.HasStatus || !s.Installed || !s.Renting || s.Bikes < 0 {
             return 0
         }
-        if s.bikes < 0 { return 0 }
-        return s.bikes
+        return s.Bikes
     }
-    returnable := func(id string) int {
-        s, ok := stByID[id]
-        if !ok {
+    returnable := func(s BikeStation) int {
+        if !s.HasStatus || !s.Installed || !s.Returning {
             return 0
         }
-        if s.docks != nil {
-            if *s.docks < 0 { return 0 }
-            return *s.docks
+        if s.Docks != nil {
+            if *s.Docks < 0 { return 0 }
+            return *s.Docks
         }
-        cap := capByID[id]
-        v := cap - s.bikes
+        v := s.Capacity - s.Bikes
         if v < 0 { return 0 }
         return v
     }
 
-    // Sum for each group
-    for _, id := range stationPrimaryIDs {
-        out.Station.Rentable += rentable(id)
-        out.Station.Returnable += returnable(id)
+    now, staleAfter := f.now(), stationStaleAfter()
+    detail := func(ref string) StationDetail {
+        op, id := StationRef(ref)
+        s := snapByOp[op].Stations[id]
+        d := StationDetail{
+            ID: id, Operator: op, Name: s.Name, Lat: s.Lat, Lon: s.Lon, Capacity: s.Capacity,
+            Rentable: rentable(s), Returnable: returnable(s),
+        }
+        if !s.HasStatus {
+            d.Missing = true
+            return d
+        }
+        d.BikesDisabled = s.BikesDisabled
+        // Feeds count available bikes, so clamp to what can actually be rented.
+        d.Charged = min(s.Charged, d.Rentable)
+        d.ChargeUnknown = min(s.ChargeUnknown, d.Rentable-d.Charged)
+        d.Electric = min(s.Electric, d.Rentable)
+        d.Installed, d.Renting, d.Returning = s.Installed, s.Renting, s.Returning
+        if !s.LastReported.IsZero() {
+            t := s.LastReported
+            d.LastReported = &t
+            d.Stale = now.Sub(t) > staleAfter
+        }
+        return d
     }
-    for _, id := range campusPrimaryIDs {
-        out.Campus.Rentable += rentable(id)
-        out.Campus.Returnable += returnable(id)
+
+    sum := func(refs []string, secondary bool) groupTotals {
+        t := groupTotals{Stations: make([]StationDetail, 0, len(refs)), Operators: map[string]OperatorTotals{}}
+        for _, ref := range refs {
+            d := detail(ref)
+            d.Secondary = secondary
+            t.Rentable += d.Rentable
+            t.Returnable += d.Returnable
+            t.Charged += d.Charged
+            t.ChargeUnknown += d.ChargeUnknown
+            ot := t.Operators[d.Operator]
+            ot.Rentable += d.Rentable
+            ot.Returnable += d.Returnable
+            t.Operators[d.Operator] = ot
+            t.Stations = append(t.Stations, d)
+            if d.Missing {
+                t.MissingStations = append(t.MissingStations, ref)
+            } else if d.Stale {
+                t.StaleStations = append(t.StaleStations, ref)
+            }
+        }
+        return t
+    }
+
+    // Sum for each group
+    out.Groups = make(map[string]groupTotals, len(groups))
+    for name, g := range groups {
+        t := sum(g.Primary, false)
+        if len(g.Secondary) > 0 {
+            fb := sum(g.Secondary, true)
+            t.Fallback = &fb
+        }
+        out.Groups[name] = t
+        if len(t.MissingStations)+len(t.StaleStations) > 0 {
+            log.Printf("[warn] bike group %s: missing %v, stale %v", name, t.MissingStations, t.StaleStations)
+        }
     }
 
     return out, nil
@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"optimal-rion/server/controller/gbfs"
+)
+
+// helloDiscovery is a GBFS 2.x discovery file for the default operator.
+const helloDiscovery = `{"ttl": 60, "version": "2.3", "data": {"ja": {"feeds": [
+	{"name": "station_information", "url": "https://api-public.odpt.org/api/v4/gbfs/hellocycling/station_information.json"},
+	{"name": "station_status", "url": "https://api-public.odpt.org/api/v4/gbfs/hellocycling/station_status.json"}
+]}}}`
+
+// gbfsServer serves fixed station_information and station_status bodies.
+func gbfsServer(tb testing.TB, info, status string) *FetchController {
+	tb.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v4/gbfs/hellocycling/gbfs.json":
+			_, _ = w.Write([]byte(helloDiscovery))
+		case "/api/v4/gbfs/hellocycling/station_information.json":
+			_, _ = w.Write([]byte(info))
+		case "/api/v4/gbfs/hellocycling/station_status.json":
+			_, _ = w.Write([]byte(status))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	tb.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	fc := NewFetchController()
+	fc.Client = &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &rewriteTransport{base: u, rt: http.DefaultTransport},
+	}
+	return fc
+}
+
+func TestFetchBikeTotals_PerStation(t *testing.T) {
+	fc := gbfsServer(t,
+		`{"ttl": 60, "data": {"stations": [
+			{"station_id": "1", "name": "駅前", "lat": 35.8, "lon": 139.5, "capacity": 10},
+			{"station_id": "2", "name": "公園", "lat": 35.9, "lon": 139.6, "capacity": 6}
+		]}}`,
+		`{"ttl": 60, "data": {"stations": [
+			{"station_id": "1", "num_bikes_available": 3, "num_docks_available": 7, "last_reported": 1700000000},
+			{"station_id": "2", "num_bikes_available": 2, "last_reported": 1700000060}
+		]}}`)
+	groups := StationGroups{GroupStation: {Primary: []string{"1", "9"}, Secondary: []string{"2"}}}
+
+	out, err := FetchBikeTotals(context.Background(), fc, groups)
+	if err != nil {
+		t.Fatalf("FetchBikeTotals error: %v", err)
+	}
+	g := out.Group(GroupStation)
+	if g.Rentable != 3 || len(g.Stations) != 2 {
+		t.Fatalf("unexpected primary totals: %+v", g)
+	}
+	s := g.Stations[0]
+	if s.ID != "1" || s.Name != "駅前" || s.Lat != 35.8 || s.Capacity != 10 || s.Rentable != 3 || s.Returnable != 7 {
+		t.Fatalf("unexpected station detail: %+v", s)
+	}
+	if s.LastReported == nil || !s.LastReported.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("unexpected last reported: %v", s.LastReported)
+	}
+	// Unknown ports are listed with zero counts and no report time.
+	if missing := g.Stations[1]; missing.ID != "9" || missing.Rentable != 0 || missing.LastReported != nil {
+		t.Fatalf("unexpected missing station: %+v", missing)
+	}
+	if g.Fallback == nil || len(g.Fallback.Stations) != 1 {
+		t.Fatalf("unexpected fallback: %+v", g.Fallback)
+	}
+	// Returnable falls back to capacity minus bikes without num_docks_available.
+	if fb := g.Fallback.Stations[0]; !fb.Secondary || fb.Returnable != 4 {
+		t.Fatalf("unexpected fallback station: %+v", fb)
+	}
+}
+
+func TestFetchBikeTotals_StationFlags(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	fc := gbfsServer(t,
+		`{"ttl": 60, "data": {"stations": [{"station_id": "4", "capacity": 8}]}}`,
+		`{"ttl": 60, "data": {"stations": [
+			{"station_id": "1", "num_bikes_available": 5, "num_docks_available": 5, "is_installed": true, "is_renting": false, "is_returning": true, "last_reported": 1700000000},
+			{"station_id": "2", "num_bikes_available": 4, "num_docks_available": 2, "is_installed": 1, "is_renting": 1, "is_returning": 0, "num_bikes_disabled": 1, "last_reported": 1700000000},
+			{"station_id": "3", "num_bikes_available": 6, "num_docks_available": 6, "is_installed": false, "last_reported": 1700000000},
+			{"station_id": "4", "num_bikes_available": 2, "last_reported": 1699990000}
+		]}}`)
+	fc.now = func() time.Time { return now }
+	t.Setenv("BIKE_STATION_STALE_AFTER", "1h")
+	groups := StationGroups{GroupStation: {Primary: []string{"1", "2", "3", "4", "5"}}}
+
+	out, err := FetchBikeTotals(context.Background(), fc, groups)
+	if err != nil {
+		t.Fatalf("FetchBikeTotals error: %v", err)
+	}
+	g := out.Group(GroupStation)
+	// 1 is not renting, 2 is not returning, 3 is not installed.
+	if g.Rentable != 4+2 || g.Returnable != 5+6 {
+		t.Fatalf("unexpected totals: rentable=%d returnable=%d", g.Rentable, g.Returnable)
+	}
+	if s := g.Stations[1]; !s.Renting || s.Returning || s.BikesDisabled != 1 {
+		t.Fatalf("unexpected flags for station 2: %+v", s)
+	}
+	if len(g.StaleStations) != 1 || g.StaleStations[0] != "4" {
+		t.Fatalf("unexpected stale stations: %v", g.StaleStations)
+	}
+	if len(g.MissingStations) != 1 || g.MissingStations[0] != "5" {
+		t.Fatalf("unexpected missing stations: %v", g.MissingStations)
+	}
+}
+
+// fakeProvider is a BikeProvider serving a fixed snapshot or error.
+type fakeProvider struct {
+	id   string
+	snap BikeSnapshot
+	err  error
+}
+
+func (p *fakeProvider) ID() string   { return p.id }
+func (p *fakeProvider) Name() string { return p.id + " bikes" }
+func (p *fakeProvider) Snapshot(context.Context, *FetchController) (BikeSnapshot, error) {
+	return p.snap, p.err
+}
+
+func TestFetchBikeTotals_MixedOperators(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	fc := NewFetchController()
+	fc.now = func() time.Time { return now }
+	station := func(bikes, docks int) BikeStation {
+		return BikeStation{HasStatus: true, Bikes: bikes, Docks: &docks, Installed: true, Renting: true, Returning: true, LastReported: now}
+	}
+	providers := BikeProviders{
+		"a": &fakeProvider{id: "a", snap: BikeSnapshot{
+			Stations: map[string]BikeStation{"1": station(2, 3)},
+			DataAge:  DataAge{FetchedAt: now.Add(-time.Minute), AgeSeconds: 60},
+		}},
+		"b": &fakeProvider{id: "b", snap: BikeSnapshot{
+			Stations: map[string]BikeStation{"1": station(5, 1)},
+			DataAge:  DataAge{FetchedAt: now, Stale: true},
+		}},
+		"c": &fakeProvider{id: "c", err: errors.New("down")},
+	}
+	groups := StationGroups{GroupStation: {Primary: []string{"a:1", "b:1", "c:1", "x:1"}}}
+
+	out, err := fetchBikeTotals(context.Background(), fc, providers, groups)
+	if err != nil {
+		t.Fatalf("fetchBikeTotals error: %v", err)
+	}
+	g := out.Group(GroupStation)
+	if g.Rentable != 7 || g.Returnable != 4 {
+		t.Fatalf("unexpected totals: %+v", g)
+	}
+	if g.Operators["a"].Rentable != 2 || g.Operators["b"].Rentable != 5 || g.Operators["c"].Rentable != 0 {
+		t.Fatalf("unexpected operator breakdown: %+v", g.Operators)
+	}
+	if len(g.MissingStations) != 2 || g.MissingStations[0] != "c:1" || g.MissingStations[1] != "x:1" {
+		t.Fatalf("unexpected missing stations: %v", g.MissingStations)
+	}
+	// Oldest fetch wins, stale if any operator is.
+	if out.AgeSeconds != 60 || !out.Stale {
+		t.Fatalf("unexpected data age: %+v", out.DataAge)
+	}
+	errs := map[string]bool{}
+	for _, o := range out.Operators {
+		errs[o.ID] = o.Err != nil
+	}
+	if errs["a"] || errs["b"] || !errs["c"] || !errs["x"] {
+		t.Fatalf("unexpected operator errors: %+v", out.Operators)
+	}
+
+	// Every operator failing fails the fetch.
+	groups = StationGroups{GroupStation: {Primary: []string{"c:1"}}}
+	if _, err := fetchBikeTotals(context.Background(), fc, providers, groups); err == nil {
+		t.Fatalf("expected error when no operator is available")
+	}
+}
+
+func TestStationRef(t *testing.T) {
+	if op, id := StationRef("6504"); op != DefaultOperator || id != "6504" {
+		t.Fatalf("bare id: %s %s", op, id)
+	}
+	if op, id := StationRef("docomo:00010001"); op != OperatorDocomo || id != "00010001" {
+		t.Fatalf("prefixed id: %s %s", op, id)
+	}
+}
+
+func TestCharge(t *testing.T) {
+	types := map[string]gbfs.VehicleType{
+		"e": {VehicleTypeID: "e", PropulsionType: gbfs.PropulsionElectricAssist, MaxRangeMeters: 10000},
+		"h": {VehicleTypeID: "h", PropulsionType: "human"},
+	}
+	rng := func(m float64) *float64 { return &m }
+	status := gbfs.StationStatus{NumBikesAvailable: 5, VehicleTypesAvailable: []gbfs.VehicleTypeCount{
+		{VehicleTypeID: "e", Count: 4}, {VehicleTypeID: "h", Count: 1},
+	}}
+
+	// Per-vehicle data: one full, one flat, one human-powered; two unlisted.
+	vehicles := []gbfs.Vehicle{
+		{VehicleTypeID: "e", CurrentRangeMeters: rng(5000)},
+		{VehicleTypeID: "e", CurrentFuelPercent: rng(0.1)},
+		{VehicleTypeID: "h"},
+	}
+	if e, c, u := charge(status, vehicles, types, 3000); e != 4 || c != 2 || u != 2 {
+		t.Fatalf("per-vehicle: electric=%d charged=%d unknown=%d", e, c, u)
+	}
+	// Type counts only: human-powered bikes are usable, e-bikes unknown.
+	if e, c, u := charge(status, nil, types, 3000); e != 4 || c != 1 || u != 4 {
+		t.Fatalf("type counts: electric=%d charged=%d unknown=%d", e, c, u)
+	}
+	// Nothing but the raw count.
+	if e, c, u := charge(gbfs.StationStatus{NumBikesAvailable: 3}, nil, nil, 3000); e != 0 || c != 0 || u != 3 {
+		t.Fatalf("raw count: electric=%d charged=%d unknown=%d", e, c, u)
+	}
+}
@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"optimal-rion/server/controller/gbfs"
+)
+
+// BikeProvider is a bike-share operator whose stations can appear in
+// station groups.
+type BikeProvider interface {
+	// ID is the prefix used in station references, e.g. "docomo:1234".
+	ID() string
+	Name() string
+	// Snapshot returns the operator's current stations keyed by station ID.
+	Snapshot(ctx context.Context, f *FetchController) (BikeSnapshot, error)
+}
+
+// BikeSnapshot is one operator's stations at a point in time.
+type BikeSnapshot struct {
+	Stations map[string]BikeStation
+	DataAge
+}
+
+// BikeStation merges a station's static information and live status.
+// HasStatus is false for stations absent from the status feed.
+type BikeStation struct {
+	Name     string
+	Lat, Lon float64
+	Capacity int
+
+	HasStatus                     bool
+	Bikes, BikesDisabled          int
+	Docks                         *int
+	Installed, Renting, Returning bool
+	LastReported                  time.Time
+
+	// Electric counts available battery-assisted bikes. Of the available
+	// bikes, Charged have enough range (or need no battery) and
+	// ChargeUnknown could not be classified from the operator's feeds.
+	Electric, Charged, ChargeUnknown int
+}
+
+// defaultBikeMinRange is the range an e-bike needs to count as charged;
+// BIKE_MIN_RANGE_METERS overrides it.
+const defaultBikeMinRange = 3000.0
+
+func bikeMinRange() float64 {
+	if v := os.Getenv("BIKE_MIN_RANGE_METERS"); v != "" {
+		if m, err := strconv.ParseFloat(v, 64); err == nil && m >= 0 {
+			return m
+		}
+		log.Printf("[warn] ignoring invalid BIKE_MIN_RANGE_METERS %q", v)
+	}
+	return defaultBikeMinRange
+}
+
+// Operator IDs of the built-in providers.
+const (
+	OperatorHelloCycling = "hellocycling"
+	OperatorDocomo       = "docomo"
+)
+
+// DefaultOperator owns station references without an operator prefix.
+const DefaultOperator = OperatorHelloCycling
+
+// Built-in GBFS discovery files, published through the ODPT open data API.
+const (
+	helloDiscoveryURL  = "https://api-public.odpt.org/api/v4/gbfs/hellocycling/gbfs.json"
+	docomoDiscoveryURL = "https://api-public.odpt.org/api/v4/gbfs/docomo-cycle-tokyo/gbfs.json"
+)
+
+// Cache policies: feeds follow their own ttl/last_updated, except
+// station_information, which rarely changes.
+var (
+	gbfsDiscoveryPolicy = CachePolicy{TTL: time.Hour, MaxStale: 24 * time.Hour, TTLFromBody: gbfs.FreshFor}
+	gbfsInfoPolicy      = CachePolicy{TTL: 24 * time.Hour, MaxStale: 24 * time.Hour}
+	gbfsStatusPolicy    = CachePolicy{TTL: time.Minute, MaxStale: 5 * time.Minute, TTLFromBody: gbfs.FreshFor}
+)
+
+// GBFSProvider is an operator publishing GBFS feeds.
+type GBFSProvider struct {
+	OperatorID   string
+	DisplayName  string
+	DiscoveryURL string
+	// Language selects names and per-language feeds; empty means ja.
+	Language string
+}
+
+func (p *GBFSProvider) ID() string   { return p.OperatorID }
+func (p *GBFSProvider) Name() string { return p.DisplayName }
+
+func (p *GBFSProvider) client(f *FetchController) *gbfs.Client {
+	lang := p.Language
+	if lang == "" {
+		lang = "ja"
+	}
+	return &gbfs.Client{
+		DiscoveryURL: p.DiscoveryURL,
+		Language:     lang,
+		Get: func(ctx context.Context, url string, out any) error {
+			_, err := f.GetJSONCached(ctx, url, nil, gbfsDiscoveryPolicy, out)
+			return err
+		},
+	}
+}
+
+// Snapshot fetches station_status along with the optional
+// station_information, vehicle_types and vehicle_status/free_bike_status
+// feeds, all in parallel. Only station_status is required; the others add
+// names, capacities and e-bike charge, so their failures are logged.
+func (p *GBFSProvider) Snapshot(ctx context.Context, f *FetchController) (BikeSnapshot, error) {
+	var out BikeSnapshot
+
+	client := p.client(f)
+	disc, err := client.Discover(ctx)
+	if err != nil {
+		return out, err
+	}
+	statusURL, ok := disc.FeedURL(gbfs.FeedStationStatus, client.Language)
+	if !ok {
+		return out, fmt.Errorf("gbfs: %s does not list %s", client.DiscoveryURL, gbfs.FeedStationStatus)
+	}
+
+	var (
+		info     gbfs.StationInformation
+		types    gbfs.VehicleTypesFeed
+		vehicles gbfs.VehicleStatusFeed
+		wg       sync.WaitGroup
+	)
+	optional := func(policy CachePolicy, out any, names ...string) {
+		for _, name := range names {
+			u, ok := disc.FeedURL(name, client.Language)
+			if !ok {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := f.GetJSONCached(ctx, u, nil, policy, out); err != nil {
+					log.Printf("[warn] %s %s unavailable: %v", p.OperatorID, name, err)
+				}
+			}()
+			return
+		}
+	}
+	optional(gbfsInfoPolicy, &info, gbfs.FeedStationInformation)
+	optional(gbfsInfoPolicy, &types, gbfs.FeedVehicleTypes)
+	optional(gbfsStatusPolicy, &vehicles, gbfs.FeedVehicleStatus, gbfs.FeedFreeBikeStatus)
+	var status gbfs.StationStatusFeed
+	meta, err := f.GetJSONCached(ctx, statusURL, nil, gbfsStatusPolicy, &status)
+	wg.Wait()
+	if err != nil {
+		return out, err
+	}
+	out.DataAge = dataAge(meta)
+
+	out.Stations = make(map[string]BikeStation, len(status.Data.Stations))
+	for _, s := range info.Data.Stations {
+		out.Stations[s.StationID] = BikeStation{
+			Name: s.Name.In(client.Language), Lat: s.Lat, Lon: s.Lon, Capacity: s.Capacity,
+		}
+	}
+	typeByID := map[string]gbfs.VehicleType{}
+	for _, t := range types.Data.VehicleTypes {
+		typeByID[t.VehicleTypeID] = t
+	}
+	docked := map[string][]gbfs.Vehicle{}
+	for _, v := range vehicles.Vehicles {
+		if v.StationID != "" && !v.IsReserved.Or(false) && !v.IsDisabled.Or(false) {
+			docked[v.StationID] = append(docked[v.StationID], v)
+		}
+	}
+	minRange := bikeMinRange()
+	for _, s := range status.Data.Stations {
+		st := out.Stations[s.StationID]
+		st.HasStatus = true
+		st.Bikes, st.BikesDisabled, st.Docks = s.NumBikesAvailable, s.NumBikesDisabled, s.NumDocksAvailable
+		st.Installed, st.Renting, st.Returning = s.IsInstalled.Or(true), s.IsRenting.Or(true), s.IsReturning.Or(true)
+		st.LastReported = s.LastReported.Time
+		st.Electric, st.Charged, st.ChargeUnknown = charge(s, docked[s.StationID], typeByID, minRange)
+		out.Stations[s.StationID] = st
+	}
+	return out, nil
+}
+
+// charge classifies a station's available bikes. Per-vehicle range wins;
+// otherwise vehicle_types_available tells bikes without a battery (always
+// usable) from e-bikes of unknown charge. Bikes of unknown type are
+// unknown.
+func charge(s gbfs.StationStatus, vehicles []gbfs.Vehicle, types map[string]gbfs.VehicleType, minRange float64) (electric, charged, unknown int) {
+	for _, c := range s.VehicleTypesAvailable {
+		if types[c.VehicleTypeID].Battery() {
+			electric += c.Count
+		}
+	}
+	if len(vehicles) > 0 {
+		known := 0
+		for _, v := range vehicles {
+			t, ok := types[v.VehicleTypeID]
+			if ok && !t.Battery() {
+				known++
+				charged++
+				continue
+			}
+			if r, ok := v.RangeMeters(t); ok {
+				known++
+				if r >= minRange {
+					charged++
+				}
+			}
+		}
+		return electric, charged, max(0, s.NumBikesAvailable-known)
+	}
+	if len(s.VehicleTypesAvailable) > 0 {
+		for _, c := range s.VehicleTypesAvailable {
+			if t, ok := types[c.VehicleTypeID]; ok && !t.Battery() {
+				charged += c.Count
+			}
+		}
+		return electric, charged, max(0, s.NumBikesAvailable-charged)
+	}
+	return electric, 0, max(0, s.NumBikesAvailable)
+}
+
+// BikeProviders maps operator IDs to providers.
+type BikeProviders map[string]BikeProvider
+
+// DefaultBikeProviders are the operators around the Niiza campus.
+func DefaultBikeProviders() BikeProviders {
+	return BikeProviders{
+		OperatorHelloCycling: &GBFSProvider{OperatorID: OperatorHelloCycling, DisplayName: "HELLO CYCLING", DiscoveryURL: helloDiscoveryURL},
+		OperatorDocomo:       &GBFSProvider{OperatorID: OperatorDocomo, DisplayName: "ドコモ・バイクシェア", DiscoveryURL: docomoDiscoveryURL},
+	}
+}
+
+// bikeProviders returns the default providers with environment overrides:
+// BIKE_GBFS_URL replaces the default operator's discovery file,
+// BIKE_GBFS_LANG sets every operator's language, and BIKE_OPERATORS adds
+// or replaces GBFS operators as comma-separated id=discovery-url pairs.
+func bikeProviders() BikeProviders {
+	ps := DefaultBikeProviders()
+	if u := os.Getenv("BIKE_GBFS_URL"); u != "" {
+		ps[DefaultOperator].(*GBFSProvider).DiscoveryURL = u
+	}
+	for _, kv := range splitIDs(os.Getenv("BIKE_OPERATORS")) {
+		id, u, ok := strings.Cut(kv, "=")
+		if !ok || id == "" || u == "" {
+			log.Printf("[warn] ignoring BIKE_OPERATORS entry %q", kv)
+			continue
+		}
+		ps[id] = &GBFSProvider{OperatorID: id, DisplayName: id, DiscoveryURL: u}
+	}
+	if lang := os.Getenv("BIKE_GBFS_LANG"); lang != "" {
+		for _, p := range ps {
+			if g, ok := p.(*GBFSProvider); ok {
+				g.Language = lang
+			}
+		}
+	}
+	return ps
+}
+
+// StationRef splits a station reference into operator and station ID.
+// References without an "operator:" prefix belong to DefaultOperator.
+func StationRef(ref string) (operator, id string) {
+	if op, id, ok := strings.Cut(ref, ":"); ok {
+		return op, id
+	}
+	return DefaultOperator, ref
+}
+
+// operatorsOf lists the operators referenced by groups, sorted.
+func operatorsOf(groups StationGroups) []string {
+	seen := map[string]bool{}
+	for _, g := range groups {
+		for _, ids := range [][]string{g.Primary, g.Secondary} {
+			for _, ref := range ids {
+				op, _ := StationRef(ref)
+				seen[op] = true
+			}
+		}
+	}
+	ops := make([]string, 0, len(seen))
+	for op := range seen {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	return ops
+}
@@ -0,0 +1,223 @@
+// Package bus loads the campus shuttle timetable and answers
+// "when is the next bus?" for either direction.
+package bus
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// Direction of travel relative to the campus.
+type Direction string
+
+const (
+	ToCampus   Direction = "to-campus"
+	FromCampus Direction = "from-campus"
+)
+
+// DayType selects a timetable variant.
+type DayType string
+
+const (
+	Weekday  DayType = "weekday"
+	Saturday DayType = "saturday"
+	Holiday  DayType = "holiday"
+	// NoService marks days without any buses (e.g. campus closure).
+	NoService DayType = "none"
+)
+
+// DayResolver picks the timetable variant for a date. The note explains
+// the choice (e.g. a holiday name) and may be empty.
+type DayResolver interface {
+	Resolve(at time.Time) (DayType, string)
+}
+
+// weekdayResolver is the fallback used when no calendar is configured.
+type weekdayResolver struct{}
+
+func (weekdayResolver) Resolve(at time.Time) (DayType, string) { return DayTypeFor(at), "" }
+
+// JST is the timezone the timetable is written in. Japan has no DST, so a
+// fixed zone avoids depending on the host's tzdata.
+var JST = time.FixedZone("Asia/Tokyo", 9*60*60)
+
+//go:embed timetable.json
+var defaultTimetable []byte
+
+// timetableFile is the on-disk JSON shape.
+type timetableFile struct {
+	ToCampus   routeFile `json:"toCampus"`
+	FromCampus routeFile `json:"fromCampus"`
+}
+
+type routeFile struct {
+	From       string               `json:"from"`
+	To         string               `json:"to"`
+	Departures map[DayType][]string `json:"departures"`
+}
+
+type route struct {
+	from, to string
+	// minutes since local midnight, sorted ascending
+	departures map[DayType][]int
+}
+
+// Timetable holds departures for both directions and every day type.
+type Timetable struct {
+	routes map[Direction]route
+	days   DayResolver
+}
+
+// Departure is a single scheduled bus.
+type Departure struct {
+	Time         time.Time `json:"time"`
+	DayType      DayType   `json:"dayType"`
+	MinutesUntil int       `json:"minutesUntil"`
+}
+
+// Default returns the timetable embedded in the binary.
+func Default() *Timetable {
+	t, err := Parse(bytes.NewReader(defaultTimetable))
+	if err != nil {
+		panic(fmt.Sprintf("bus: embedded timetable is invalid: %v", err))
+	}
+	return t
+}
+
+// Load reads a timetable JSON file. An empty path returns Default().
+func Load(path string) (*Timetable, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse decodes a timetable from JSON.
+func Parse(r io.Reader) (*Timetable, error) {
+	var tf timetableFile
+	if err := json.NewDecoder(r).Decode(&tf); err != nil {
+		return nil, err
+	}
+	t := &Timetable{routes: map[Direction]route{}, days: weekdayResolver{}}
+	for dir, rf := range map[Direction]routeFile{ToCampus: tf.ToCampus, FromCampus: tf.FromCampus} {
+		rt := route{from: rf.From, to: rf.To, departures: map[DayType][]int{}}
+		for day, times := range rf.Departures {
+			mins := make([]int, 0, len(times))
+			for _, s := range times {
+				m, err := parseClock(s)
+				if err != nil {
+					return nil, fmt.Errorf("bus: %s %s: %w", dir, day, err)
+				}
+				mins = append(mins, m)
+			}
+			sort.Ints(mins)
+			rt.departures[day] = mins
+		}
+		t.routes[dir] = rt
+	}
+	return t, nil
+}
+
+// parseClock converts "HH:MM" to minutes since midnight.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// Stops returns the boarding and alighting stop names for a direction;
+// empty without a timetable.
+func (t *Timetable) Stops(dir Direction) (from, to string) {
+	if t == nil {
+		return "", ""
+	}
+	rt := t.routes[dir]
+	return rt.from, rt.to
+}
+
+// UseCalendar replaces the weekday-only day type resolution. It must be
+// called before the timetable is shared between goroutines. It does
+// nothing without a timetable.
+func (t *Timetable) UseCalendar(r DayResolver) {
+	if t == nil {
+		return
+	}
+	t.days = r
+}
+
+// DayType resolves the timetable variant running on a date. Without a
+// timetable or resolver it goes by weekday alone.
+func (t *Timetable) DayType(at time.Time) (DayType, string) {
+	if t == nil || t.days == nil {
+		return DayTypeFor(at), ""
+	}
+	return t.days.Resolve(at)
+}
+
+// DayTypeFor picks the timetable variant for a date by weekday alone.
+func DayTypeFor(at time.Time) DayType {
+	switch at.In(JST).Weekday() {
+	case time.Sunday:
+		return Holiday
+	case time.Saturday:
+		return Saturday
+	default:
+		return Weekday
+	}
+}
+
+// maxLookaheadDays bounds how far NextDepartures rolls over into following days.
+const maxLookaheadDays = 7
+
+// NextDepartures returns up to n departures at or after at, rolling over
+// into following days when today's buses have run out. Without a
+// timetable there are none.
+func (t *Timetable) NextDepartures(dir Direction, at time.Time, n int) []Departure {
+	if t == nil || n <= 0 {
+		return nil
+	}
+	rt, ok := t.routes[dir]
+	if !ok {
+		return nil
+	}
+	local := at.In(JST).Truncate(time.Minute)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, JST)
+	nowMin := local.Hour()*60 + local.Minute()
+
+	var out []Departure
+	for d := 0; d < maxLookaheadDays && len(out) < n; d++ {
+		day := midnight.AddDate(0, 0, d)
+		dt, _ := t.DayType(day)
+		for _, m := range rt.departures[dt] {
+			if d == 0 && m < nowMin {
+				continue
+			}
+			dep := day.Add(time.Duration(m) * time.Minute)
+			out = append(out, Departure{
+				Time:         dep,
+				DayType:      dt,
+				MinutesUntil: int(dep.Sub(local) / time.Minute),
+			})
+			if len(out) == n {
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package bus
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTimetable = `{
+  "toCampus": {
+    "from": "A", "to": "B",
+    "departures": {
+      "weekday": ["08:10", "07:50", "08:30"],
+      "saturday": ["09:00"],
+      "holiday": []
+    }
+  },
+  "fromCampus": {"from": "B", "to": "A", "departures": {"weekday": ["17:00"]}}
+}`
+
+func TestNextDepartures_SameDay(t *testing.T) {
+	tt, err := Parse(strings.NewReader(testTimetable))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	// Wednesday 2025-10-08 08:00 JST
+	at := time.Date(2025, 10, 8, 8, 0, 0, 0, JST)
+	deps := tt.NextDepartures(ToCampus, at, 2)
+	if len(deps) != 2 {
+		t.Fatalf("unexpected count: got %d want 2", len(deps))
+	}
+	if deps[0].Time.Format("15:04") != "08:10" || deps[0].MinutesUntil != 10 {
+		t.Fatalf("unexpected first departure: %+v", deps[0])
+	}
+	if deps[1].Time.Format("15:04") != "08:30" {
+		t.Fatalf("unexpected second departure: %+v", deps[1])
+	}
+}
+
+func TestNextDepartures_RollsOverToSaturday(t *testing.T) {
+	tt, err := Parse(strings.NewReader(testTimetable))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	// Friday 2025-10-10 20:00 JST; next bus is Saturday 09:00
+	at := time.Date(2025, 10, 10, 20, 0, 0, 0, JST)
+	deps := tt.NextDepartures(ToCampus, at, 1)
+	if len(deps) != 1 {
+		t.Fatalf("unexpected count: got %d want 1", len(deps))
+	}
+	if deps[0].DayType != Saturday || deps[0].Time.Day() != 11 || deps[0].Time.Format("15:04") != "09:00" {
+		t.Fatalf("unexpected departure: %+v", deps[0])
+	}
+}
+
+func TestParse_InvalidTime(t *testing.T) {
+	_, err := Parse(strings.NewReader(`{"toCampus": {"departures": {"weekday": ["25:00"]}}}`))
+	if err == nil {
+		t.Fatalf("expected error for invalid time")
+	}
+}
+
+func TestDefault_Loads(t *testing.T) {
+	tt := Default()
+	if from, to := tt.Stops(ToCampus); from == "" || to == "" {
+		t.Fatalf("missing stop names: %q %q", from, to)
+	}
+}
+
+func TestNilTimetable(t *testing.T) {
+	var tt *Timetable
+	at := time.Date(2025, 10, 11, 8, 0, 0, 0, JST)
+	if from, to := tt.Stops(ToCampus); from != "" || to != "" {
+		t.Fatalf("unexpected stops: %q %q", from, to)
+	}
+	if deps := tt.NextDepartures(ToCampus, at, 3); deps != nil {
+		t.Fatalf("unexpected departures: %+v", deps)
+	}
+	if day, _ := tt.DayType(at); day != Saturday {
+		t.Fatalf("unexpected day type: %s", day)
+	}
+	tt.UseCalendar(nil)
+}
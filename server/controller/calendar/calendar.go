@@ -0,0 +1,143 @@
+// Package calendar resolves which bus timetable variant runs on a date,
+// combining Japanese public holidays with the university's academic
+// calendar (vacations, exam periods and special-schedule days).
+package calendar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"optimal-rion/server/controller/bus"
+)
+
+// Kind classifies an academic calendar entry.
+type Kind string
+
+const (
+	Vacation Kind = "vacation"
+	Exam     Kind = "exam"
+	// Special days override everything else, including public holidays
+	// (e.g. a holiday on which classes are held).
+	Special Kind = "special"
+)
+
+// Entry is one period or day in the academic calendar file.
+type Entry struct {
+	Name  string `json:"name"`
+	Kind  Kind   `json:"kind"`
+	Start string `json:"start"`         // YYYY-MM-DD
+	End   string `json:"end,omitempty"` // inclusive; defaults to Start
+	// Schedule is the timetable variant that runs; empty keeps the
+	// weekday-based default.
+	Schedule bus.DayType `json:"schedule,omitempty"`
+}
+
+type entry struct {
+	Entry
+	start, end civil
+}
+
+func (e entry) contains(c civil) bool {
+	t := c.time()
+	return !t.Before(e.start.time()) && !t.After(e.end.time())
+}
+
+// Calendar resolves timetable variants. The zero value knows public
+// holidays only.
+type Calendar struct {
+	entries []entry
+}
+
+type calendarFile struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Load reads an academic calendar JSON file. An empty path returns a
+// calendar with public holidays only.
+func Load(path string) (*Calendar, error) {
+	if path == "" {
+		return &Calendar{}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse decodes an academic calendar from JSON.
+func Parse(r io.Reader) (*Calendar, error) {
+	var cf calendarFile
+	if err := json.NewDecoder(r).Decode(&cf); err != nil {
+		return nil, err
+	}
+	c := &Calendar{}
+	for _, e := range cf.Entries {
+		switch e.Kind {
+		case Vacation, Exam, Special:
+		default:
+			return nil, fmt.Errorf("calendar: %q: unknown kind %q", e.Name, e.Kind)
+		}
+		start, err := parseDate(e.Start)
+		if err != nil {
+			return nil, fmt.Errorf("calendar: %q: %w", e.Name, err)
+		}
+		end := start
+		if e.End != "" {
+			if end, err = parseDate(e.End); err != nil {
+				return nil, fmt.Errorf("calendar: %q: %w", e.Name, err)
+			}
+		}
+		if end.time().Before(start.time()) {
+			return nil, fmt.Errorf("calendar: %q: end before start", e.Name)
+		}
+		c.entries = append(c.entries, entry{Entry: e, start: start, end: end})
+	}
+	return c, nil
+}
+
+func parseDate(s string) (civil, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return civil{}, fmt.Errorf("invalid date %q", s)
+	}
+	return civilOf(t), nil
+}
+
+// lookup returns the first entry of a kind set covering the date.
+func (c *Calendar) lookup(d civil, special bool) (entry, bool) {
+	for _, e := range c.entries {
+		if (e.Kind == Special) == special && e.contains(d) {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+// Resolve implements bus.DayResolver. The note names the holiday or
+// calendar entry that decided the variant, if any.
+func (c *Calendar) Resolve(at time.Time) (bus.DayType, string) {
+	local := at.In(bus.JST)
+	d := civilOf(local)
+
+	if e, ok := c.lookup(d, true); ok && e.Schedule != "" {
+		return e.Schedule, e.Name
+	}
+	if name, ok := HolidayName(local); ok {
+		return bus.Holiday, name
+	}
+	if local.Weekday() == time.Sunday {
+		return bus.Holiday, ""
+	}
+	if e, ok := c.lookup(d, false); ok {
+		if e.Schedule != "" {
+			return e.Schedule, e.Name
+		}
+		return bus.DayTypeFor(local), e.Name
+	}
+	return bus.DayTypeFor(local), ""
+}
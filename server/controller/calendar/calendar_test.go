@@ -0,0 +1,84 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"optimal-rion/server/controller/bus"
+)
+
+func TestHolidays_2025(t *testing.T) {
+	want := []string{
+		"01-01", "01-13", "02-11", "02-23", "02-24", "03-20", "04-29", "05-03",
+		"05-04", "05-05", "05-06", "07-21", "08-11", "09-15", "09-23", "10-13",
+		"11-03", "11-23", "11-24",
+	}
+	got := Holidays(2025)
+	if len(got) != len(want) {
+		t.Fatalf("unexpected holiday count: got %d want %d (%v)", len(got), len(want), got)
+	}
+	for i, h := range got {
+		if h.Date.Format("01-02") != want[i] {
+			t.Fatalf("holiday %d: got %s want %s", i, h.Date.Format("01-02"), want[i])
+		}
+	}
+}
+
+func TestHolidayName_SubstituteAndCitizens(t *testing.T) {
+	cases := []struct {
+		date time.Time
+		name string
+	}{
+		{time.Date(2025, 5, 6, 0, 0, 0, 0, bus.JST), "振替休日"},
+		{time.Date(2026, 9, 22, 0, 0, 0, 0, bus.JST), "国民の休日"},
+		{time.Date(2026, 3, 20, 0, 0, 0, 0, bus.JST), "春分の日"},
+	}
+	for _, c := range cases {
+		name, ok := HolidayName(c.date)
+		if !ok || name != c.name {
+			t.Fatalf("%s: got %q/%v want %q", c.date.Format("2006-01-02"), name, ok, c.name)
+		}
+	}
+	if _, ok := HolidayName(time.Date(2025, 10, 14, 0, 0, 0, 0, bus.JST)); ok {
+		t.Fatalf("2025-10-14 should not be a holiday")
+	}
+}
+
+const testCalendar = `{
+  "entries": [
+    {"name": "夏季休暇", "kind": "vacation", "start": "2025-08-01", "end": "2025-09-19", "schedule": "saturday"},
+    {"name": "授業実施日", "kind": "special", "start": "2025-10-13", "schedule": "weekday"}
+  ]
+}`
+
+func TestResolve(t *testing.T) {
+	cal, err := Parse(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	cases := []struct {
+		date time.Time
+		want bus.DayType
+	}{
+		{time.Date(2025, 10, 13, 9, 0, 0, 0, bus.JST), bus.Weekday},   // holiday overridden by special day
+		{time.Date(2025, 11, 3, 9, 0, 0, 0, bus.JST), bus.Holiday},    // 文化の日
+		{time.Date(2025, 8, 11, 9, 0, 0, 0, bus.JST), bus.Holiday},    // 山の日 during vacation
+		{time.Date(2025, 8, 12, 9, 0, 0, 0, bus.JST), bus.Saturday},   // vacation schedule
+		{time.Date(2025, 10, 15, 9, 0, 0, 0, bus.JST), bus.Weekday},   // regular Wednesday
+		{time.Date(2025, 10, 19, 9, 0, 0, 0, bus.JST), bus.Holiday},   // Sunday
+		{time.Date(2025, 10, 18, 23, 0, 0, 0, time.UTC), bus.Holiday}, // Sunday in JST
+	}
+	for _, c := range cases {
+		got, _ := cal.Resolve(c.date)
+		if got != c.want {
+			t.Fatalf("%s: got %s want %s", c.date, got, c.want)
+		}
+	}
+}
+
+func TestParse_UnknownKind(t *testing.T) {
+	if _, err := Parse(strings.NewReader(`{"entries": [{"name": "x", "kind": "party", "start": "2025-01-01"}]}`)); err == nil {
+		t.Fatalf("expected error for unknown kind")
+	}
+}
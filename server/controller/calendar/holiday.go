@@ -0,0 +1,159 @@
+package calendar
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// civil is a calendar date without a time or zone.
+type civil struct {
+	y int
+	m time.Month
+	d int
+}
+
+func civilOf(t time.Time) civil {
+	y, m, d := t.Date()
+	return civil{y, m, d}
+}
+
+func (c civil) time() time.Time { return time.Date(c.y, c.m, c.d, 0, 0, 0, 0, time.UTC) }
+
+func (c civil) addDays(n int) civil { return civilOf(c.time().AddDate(0, 0, n)) }
+
+// nthMonday returns the day of month of the n-th Monday.
+func nthMonday(y int, m time.Month, n int) int {
+	first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	offset := (int(time.Monday) - int(first) + 7) % 7
+	return 1 + offset + 7*(n-1)
+}
+
+// Equinox days by the usual approximation, valid for 1980-2099.
+func vernalEquinox(y int) int {
+	return int(math.Floor(20.8431+0.242194*float64(y-1980))) - (y-1980)/4
+}
+
+func autumnalEquinox(y int) int {
+	return int(math.Floor(23.2488+0.242194*float64(y-1980))) - (y-1980)/4
+}
+
+// nationalHolidays lists the holidays defined by the National Holiday Act
+// for a year, before substitute and citizens' holidays are applied.
+// Rules follow the law as amended in 2007 and later.
+func nationalHolidays(y int) map[civil]string {
+	h := map[civil]string{}
+	add := func(m time.Month, d int, name string) { h[civil{y, m, d}] = name }
+
+	add(time.January, 1, "元日")
+	add(time.January, nthMonday(y, time.January, 2), "成人の日")
+	add(time.February, 11, "建国記念の日")
+	if y >= 2020 {
+		add(time.February, 23, "天皇誕生日")
+	}
+	add(time.March, vernalEquinox(y), "春分の日")
+	add(time.April, 29, "昭和の日")
+	add(time.May, 3, "憲法記念日")
+	add(time.May, 4, "みどりの日")
+	add(time.May, 5, "こどもの日")
+	switch y {
+	case 2020:
+		add(time.July, 23, "海の日")
+		add(time.July, 24, "スポーツの日")
+		add(time.August, 10, "山の日")
+	case 2021:
+		add(time.July, 22, "海の日")
+		add(time.July, 23, "スポーツの日")
+		add(time.August, 8, "山の日")
+	default:
+		add(time.July, nthMonday(y, time.July, 3), "海の日")
+		if y >= 2016 {
+			add(time.August, 11, "山の日")
+		}
+		name := "スポーツの日"
+		if y < 2020 {
+			name = "体育の日"
+		}
+		add(time.October, nthMonday(y, time.October, 2), name)
+	}
+	add(time.September, nthMonday(y, time.September, 3), "敬老の日")
+	add(time.September, autumnalEquinox(y), "秋分の日")
+	add(time.November, 3, "文化の日")
+	add(time.November, 23, "勤労感謝の日")
+	if y <= 2018 {
+		add(time.December, 23, "天皇誕生日")
+	}
+	if y == 2019 {
+		add(time.April, 30, "国民の休日")
+		add(time.May, 1, "即位の日")
+		add(time.May, 2, "国民の休日")
+		add(time.October, 22, "即位礼正殿の儀")
+	}
+	return h
+}
+
+// Holiday is a named public holiday. Date is midnight UTC of the civil date.
+type Holiday struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+}
+
+// holidaysOf returns every public holiday in a year, including substitute
+// holidays (振替休日) and citizens' holidays (国民の休日).
+func holidaysOf(y int) map[civil]string {
+	base := nationalHolidays(y)
+	all := map[civil]string{}
+	for c, name := range base {
+		all[c] = name
+	}
+
+	// A holiday on Sunday moves to the next day that is not a holiday.
+	for c := range base {
+		if c.time().Weekday() != time.Sunday {
+			continue
+		}
+		next := c.addDays(1)
+		for {
+			if _, ok := base[next]; !ok {
+				break
+			}
+			next = next.addDays(1)
+		}
+		all[next] = "振替休日"
+	}
+
+	// A non-holiday sandwiched between two national holidays is a holiday.
+	for c := range base {
+		mid := c.addDays(1)
+		if _, ok := base[mid]; ok {
+			continue
+		}
+		if _, ok := base[mid.addDays(1)]; !ok {
+			continue
+		}
+		if _, ok := all[mid]; !ok && mid.time().Weekday() != time.Sunday {
+			all[mid] = "国民の休日"
+		}
+	}
+
+	return all
+}
+
+// Holidays lists the public holidays of a year in date order.
+func Holidays(y int) []Holiday {
+	all := holidaysOf(y)
+	out := make([]Holiday, 0, len(all))
+	for c, name := range all {
+		out = append(out, Holiday{Date: c.time(), Name: name})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Date.Before(out[j].Date) })
+	return out
+}
+
+// HolidayName reports whether the date (in its own location) is a
+// Japanese public holiday and returns its name.
+func HolidayName(at time.Time) (string, bool) {
+	c := civilOf(at)
+	name, ok := holidaysOf(c.y)[c]
+	return name, ok
+}
@@ -0,0 +1,86 @@
+package controller
+
+import "math"
+
+// Cycling difficulty levels.
+const (
+	DifficultyEasy     = "easy"
+	DifficultyModerate = "moderate"
+	DifficultyHard     = "hard"
+	DifficultyVeryHard = "very-hard"
+)
+
+// Difficulty thresholds and weights. Score points are roughly "how much
+// harder than a calm, flat ride".
+const (
+	headwindPoints   = 0.5 // per m/s of headwind
+	tailwindPoints   = 0.2 // credit per m/s of tailwind
+	gustCrossSafe    = 6.0 // m/s of crosswind gust before it unsettles a bike
+	gustCrossPoints  = 0.4 // per m/s above gustCrossSafe
+	gradePoints      = 1.0 // per percent of average grade
+	uphillHeadwindMS = 3.0 // headwind that, uphill, earns the flag
+	uphillGrade      = 1.0 // percent
+	maxDifficulty    = 10.0
+)
+
+// DifficultyDTO rates one direction of a ride against the current wind.
+// Wind components are relative to the direction of travel. Values are
+// metric whatever units the weather is displayed in.
+type DifficultyDTO struct {
+	BearingDeg float64 `json:"bearingDeg"`
+	// HeadwindMS is negative for a tailwind; CrosswindMS is its magnitude
+	// from either side.
+	HeadwindMS      float64 `json:"headwindMs"`
+	CrosswindMS     float64 `json:"crosswindMs"`
+	GustHeadwindMS  float64 `json:"gustHeadwindMs"`
+	GustCrosswindMS float64 `json:"gustCrosswindMs"`
+	ClimbM          float64 `json:"climbM"`
+	GradePercent    float64 `json:"gradePercent"`
+	// Score is 0 (calm and flat) to 10.
+	Score float64 `json:"score"`
+	Level string  `json:"level"`
+	// HeadwindUphill flags a climb into a notable headwind.
+	HeadwindUphill bool `json:"headwindUphill"`
+}
+
+// CyclingDifficulty scores a ride on bearingDeg over distanceKM climbing
+// climbM metres in the weather w.
+func CyclingDifficulty(w WeatherDTO, bearingDeg, distanceKM, climbM float64) *DifficultyDTO {
+	rel := (float64(w.WindDeg) - bearingDeg) * math.Pi / 180
+	round := func(v float64) float64 { return math.Round(v*10) / 10 }
+
+	d := &DifficultyDTO{
+		BearingDeg:      round(bearingDeg),
+		HeadwindMS:      round(w.WindSpeedMS * math.Cos(rel)),
+		CrosswindMS:     round(math.Abs(w.WindSpeedMS * math.Sin(rel))),
+		GustHeadwindMS:  round(w.WindGustMS * math.Cos(rel)),
+		GustCrosswindMS: round(math.Abs(w.WindGustMS * math.Sin(rel))),
+		ClimbM:          climbM,
+	}
+	if distanceKM > 0 {
+		d.GradePercent = round(climbM / (distanceKM * 1000) * 100)
+	}
+
+	var score float64
+	if d.HeadwindMS >= 0 {
+		score += d.HeadwindMS * headwindPoints
+	} else {
+		score += d.HeadwindMS * tailwindPoints
+	}
+	score += math.Max(0, d.GustCrosswindMS-gustCrossSafe) * gustCrossPoints
+	score += math.Max(0, d.GradePercent) * gradePoints
+	d.Score = round(math.Min(maxDifficulty, math.Max(0, score)))
+
+	switch {
+	case d.Score >= 6:
+		d.Level = DifficultyVeryHard
+	case d.Score >= 4:
+		d.Level = DifficultyHard
+	case d.Score >= 2:
+		d.Level = DifficultyModerate
+	default:
+		d.Level = DifficultyEasy
+	}
+	d.HeadwindUphill = d.HeadwindMS >= uphillHeadwindMS && d.GradePercent >= uphillGrade
+	return d
+}
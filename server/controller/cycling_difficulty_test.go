@@ -0,0 +1,24 @@
+package controller
+
+import "testing"
+
+func TestCyclingDifficulty(t *testing.T) {
+	// Riding north, 1.5 km, 20 m up, into a 6 m/s north wind.
+	north := WeatherDTO{WindSpeedMS: 6, WindGustMS: 9, WindDeg: 0}
+	d := CyclingDifficulty(north, 0, 1.5, 20)
+	if d.HeadwindMS != 6 || d.CrosswindMS != 0 || !d.HeadwindUphill || d.Level != DifficultyHard {
+		t.Fatalf("unexpected uphill headwind rating: %+v", d)
+	}
+
+	// The way back is downhill with the wind behind.
+	back := CyclingDifficulty(north, 180, 1.5, -20)
+	if back.HeadwindMS != -6 || back.HeadwindUphill || back.Score != 0 || back.Level != DifficultyEasy {
+		t.Fatalf("unexpected downhill tailwind rating: %+v", back)
+	}
+
+	// A gusty crosswind on the flat still counts.
+	cross := CyclingDifficulty(WeatherDTO{WindSpeedMS: 8, WindGustMS: 16, WindDeg: 90}, 0, 1.5, 0)
+	if cross.CrosswindMS != 8 || cross.GustCrosswindMS != 16 || cross.HeadwindMS != 0 || cross.Score != 4 {
+		t.Fatalf("unexpected crosswind rating: %+v", cross)
+	}
+}
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the upstream host while its
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// BreakerPolicy configures the per-host circuit breakers.
+type BreakerPolicy struct {
+	// FailureThreshold consecutive failures open the breaker; zero
+	// disables circuit breaking.
+	FailureThreshold int
+	// Cooldown is how long an open breaker fast-fails before letting one
+	// probe request through (half-open).
+	Cooldown time.Duration
+}
+
+// DefaultBreakerPolicy opens after a handful of failed requests, which with
+// retries is one or two failed handler calls.
+var DefaultBreakerPolicy = BreakerPolicy{FailureThreshold: 5, Cooldown: 30 * time.Second}
+
+// Breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerStatus is a snapshot of one host's breaker.
+type BreakerStatus struct {
+	Host                string     `json:"host"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+}
+
+type breaker struct {
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	lastError string
+}
+
+type breakerSet struct {
+	mu    sync.Mutex
+	hosts map[string]*breaker
+}
+
+func newBreakerSet() *breakerSet {
+	return &breakerSet{hosts: map[string]*breaker{}}
+}
+
+func hostOf(fullURL string) string {
+	u, err := url.Parse(fullURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// isUpstreamFailure reports whether an error says the host is unhealthy.
+// Client errors such as 404 mean the host answered, so they don't count.
+func isUpstreamFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he.StatusCode >= http.StatusInternalServerError || he.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// allow decides whether a request to host may go out now.
+func (f *FetchController) allow(host string) bool {
+	if f.Breaker.FailureThreshold <= 0 {
+		return true
+	}
+	s := f.breakers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.hosts[host]
+	if !ok {
+		return true
+	}
+	switch b.state {
+	case BreakerOpen:
+		if f.now().Sub(b.openedAt) < f.Breaker.Cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		// Only one probe at a time while half-open.
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record updates host's breaker with the outcome of a request.
+func (f *FetchController) record(host string, err error) {
+	if f.Breaker.FailureThreshold <= 0 {
+		return
+	}
+	s := f.breakers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.hosts[host]
+	if !ok {
+		b = &breaker{state: BreakerClosed}
+		s.hosts[host] = b
+	}
+	b.probing = false
+	if !isUpstreamFailure(err) {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == BreakerHalfOpen || b.failures >= f.Breaker.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = f.now()
+	}
+}
+
+// guardedFetch runs fetchBody behind the host's circuit breaker.
+func (f *FetchController) guardedFetch(ctx context.Context, fullURL string, headers map[string]string) ([]byte, error) {
+	host := hostOf(fullURL)
+	if !f.allow(host) {
+		return nil, ErrCircuitOpen
+	}
+	body, err := f.fetchBody(ctx, fullURL, headers)
+	f.record(host, err)
+	return body, err
+}
+
+// BreakerStates returns a snapshot of every upstream host's breaker.
+func (f *FetchController) BreakerStates() []BreakerStatus {
+	s := f.breakers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]BreakerStatus, 0, len(s.hosts))
+	for host, b := range s.hosts {
+		st := BreakerStatus{Host: host, State: b.state, ConsecutiveFailures: b.failures, LastError: b.lastError}
+		if b.state != BreakerClosed {
+			opened := b.openedAt
+			retry := opened.Add(f.Breaker.Cooldown)
+			st.OpenedAt, st.RetryAt = &opened, &retry
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func breakerFetch(clock *fakeClock) *FetchController {
+	fc := NewFetchController()
+	fc.now = clock.Now
+	fc.Retry = RetryPolicy{MaxAttempts: 1}
+	fc.Breaker = BreakerPolicy{FailureThreshold: 2, Cooldown: time.Minute}
+	return fc
+}
+
+func TestBreaker_OpensAndHalfOpens(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv, hits := upstream{Fail: func(int32) bool { return down.Load() }, Status: http.StatusServiceUnavailable}.serve(t)
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	fc := breakerFetch(clock)
+	var out struct {
+		OK bool `json:"ok"`
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := fc.GetJSON(context.Background(), srv.URL, nil, &out); err == nil {
+			t.Fatalf("expected upstream error")
+		}
+	}
+	if err := fc.GetJSON(context.Background(), srv.URL, nil, &out); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 2 {
+		t.Fatalf("open breaker should not reach upstream, hits=%d", got)
+	}
+	if st := fc.BreakerStates(); len(st) != 1 || st[0].State != BreakerOpen {
+		t.Fatalf("unexpected breaker states: %+v", st)
+	}
+
+	// After the cooldown a probe goes through and closes the breaker.
+	down.Store(false)
+	clock.Advance(2 * time.Minute)
+	if err := fc.GetJSON(context.Background(), srv.URL, nil, &out); err != nil || !out.OK {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if st := fc.BreakerStates(); st[0].State != BreakerClosed || st[0].ConsecutiveFailures != 0 {
+		t.Fatalf("expected closed breaker, got %+v", st[0])
+	}
+}
+
+func TestBreaker_ServesExpiredCacheWhileOpen(t *testing.T) {
+	var down atomic.Bool
+	srv, _ := upstream{Fail: func(int32) bool { return down.Load() }, Status: http.StatusServiceUnavailable}.serve(t)
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	fc := breakerFetch(clock)
+	policy := CachePolicy{TTL: time.Second, MaxStale: time.Second}
+	var out struct {
+		OK bool `json:"ok"`
+	}
+
+	if _, err := fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out); err != nil {
+		t.Fatalf("initial fetch: %v", err)
+	}
+	down.Store(true)
+	clock.Advance(time.Hour)
+	for i := 0; i < 2; i++ {
+		_, _ = fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out)
+	}
+
+	out.OK = false
+	meta, err := fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out)
+	if err != nil || !out.OK || !meta.Stale || meta.Age != time.Hour {
+		t.Fatalf("expected expired cache while open: err=%v ok=%v meta=%+v", err, out.OK, meta)
+	}
+}
+
+func TestNewFetchController_OpensBreakerByDefault(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv, hits := upstream{Fail: func(int32) bool { return down.Load() }, Status: http.StatusServiceUnavailable}.serve(t)
+	fc := NewFetchController()
+	var out struct{}
+
+	var err error
+	for i := 0; i < 3 && !errors.Is(err, ErrCircuitOpen); i++ {
+		err = fc.GetJSON(context.Background(), srv.URL, nil, &out)
+	}
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the default breaker to open, got %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != int32(DefaultBreakerPolicy.FailureThreshold) {
+		t.Fatalf("expected %d upstream requests before opening, got %d", DefaultBreakerPolicy.FailureThreshold, got)
+	}
+	if st := fc.BreakerStates(); len(st) != 1 || st[0].State != BreakerOpen {
+		t.Fatalf("unexpected breaker states: %+v", st)
+	}
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// CachePolicy controls how GetJSONCached stores a response.
+type CachePolicy struct {
+	// TTL is how long a response is served without revalidation.
+	TTL time.Duration
+	// MaxStale is how long past TTL a response may still be served while
+	// a background refresh runs. Beyond that, callers wait for a fetch.
+	MaxStale time.Duration
+	// TTLFromBody optionally derives the TTL from the payload fetched at
+	// fetchedAt (e.g. GBFS ttl/last_updated). TTL is used when it returns
+	// false.
+	TTLFromBody func(body []byte, fetchedAt time.Time) (time.Duration, bool)
+}
+
+// FetchMeta describes where a cached response came from.
+type FetchMeta struct {
+	FetchedAt time.Time
+	Age       time.Duration
+	// Stale is set when the response is past its TTL and either a refresh
+	// is running in the background or the host's breaker is open.
+	Stale bool
+}
+
+// DataAge is embedded in DTOs to tell callers how old their data is.
+// Handlers surface it through each section's status.
+type DataAge struct {
+	FetchedAt  time.Time `json:"fetchedAt"`
+	AgeSeconds int       `json:"ageSeconds"`
+	Stale      bool      `json:"stale,omitempty"`
+}
+
+func dataAge(m FetchMeta) DataAge {
+	return DataAge{FetchedAt: m.FetchedAt, AgeSeconds: int(m.Age / time.Second), Stale: m.Stale}
+}
+
+// maxCacheEntries bounds memory when callers vary query parameters.
+const maxCacheEntries = 256
+
+type cacheEntry struct {
+	body       []byte
+	fetchedAt  time.Time
+	ttl        time.Duration
+	maxStale   time.Duration
+	refreshing bool
+}
+
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: map[string]*cacheEntry{}}
+}
+
+// GetJSONCached is GetJSON backed by an in-memory cache keyed by URL.
+// Fresh entries are served directly; stale entries within MaxStale are
+// served while one background refresh updates them.
+func (f *FetchController) GetJSONCached(ctx context.Context, fullURL string, headers map[string]string, policy CachePolicy, out any) (FetchMeta, error) {
+	now := f.now()
+
+	f.cache.mu.Lock()
+	e, ok := f.cache.entries[fullURL]
+	if ok {
+		age := now.Sub(e.fetchedAt)
+		if age <= e.ttl+e.maxStale {
+			body := e.body
+			meta := FetchMeta{FetchedAt: e.fetchedAt, Age: age}
+			if age > e.ttl {
+				meta.Stale = true
+				if !e.refreshing {
+					e.refreshing = true
+					go f.refresh(fullURL, headers, policy)
+				}
+			}
+			f.cache.mu.Unlock()
+			return meta, json.Unmarshal(body, out)
+		}
+	}
+	f.cache.mu.Unlock()
+
+	body, err := f.getBody(ctx, fullURL, headers)
+	if err != nil {
+		// An open breaker means the host is known to be down: expired
+		// data beats no data.
+		if ok && errors.Is(err, ErrCircuitOpen) {
+			f.cache.mu.Lock()
+			body, fetchedAt := e.body, e.fetchedAt
+			f.cache.mu.Unlock()
+			return FetchMeta{FetchedAt: fetchedAt, Age: now.Sub(fetchedAt), Stale: true}, json.Unmarshal(body, out)
+		}
+		return FetchMeta{}, err
+	}
+	fetchedAt := f.now()
+	f.store(fullURL, body, fetchedAt, policy)
+	return FetchMeta{FetchedAt: fetchedAt}, json.Unmarshal(body, out)
+}
+
+// refresh re-fetches a stale entry outside any request's lifetime.
+func (f *FetchController) refresh(fullURL string, headers map[string]string, policy CachePolicy) {
+	ctx, cancel := f.sharedContext(context.Background())
+	defer cancel()
+
+	body, err := f.getBody(ctx, fullURL, headers)
+	if err != nil {
+		log.Printf("[warn] cache refresh %s: %v", redactURL(fullURL), err)
+		f.cache.mu.Lock()
+		if e, ok := f.cache.entries[fullURL]; ok {
+			e.refreshing = false
+		}
+		f.cache.mu.Unlock()
+		return
+	}
+	f.store(fullURL, body, f.now(), policy)
+}
+
+func (f *FetchController) store(fullURL string, body []byte, fetchedAt time.Time, policy CachePolicy) {
+	ttl := policy.TTL
+	if policy.TTLFromBody != nil {
+		if d, ok := policy.TTLFromBody(body, fetchedAt); ok {
+			ttl = d
+		}
+	}
+
+	f.cache.mu.Lock()
+	defer f.cache.mu.Unlock()
+	f.cache.entries[fullURL] = &cacheEntry{body: body, fetchedAt: fetchedAt, ttl: ttl, maxStale: policy.MaxStale}
+	if len(f.cache.entries) > maxCacheEntries {
+		f.cache.evict(fetchedAt)
+	}
+}
+
+// evict drops expired entries, then the oldest ones until under the limit.
+// Callers must hold mu.
+func (c *responseCache) evict(now time.Time) {
+	for k, e := range c.entries {
+		if now.Sub(e.fetchedAt) > e.ttl+e.maxStale {
+			delete(c.entries, k)
+		}
+	}
+	for len(c.entries) > maxCacheEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range c.entries {
+			if oldestKey == "" || e.fetchedAt.Before(oldest) {
+				oldestKey, oldest = k, e.fetchedAt
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
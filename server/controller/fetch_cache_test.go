@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"optimal-rion/server/controller/gbfs"
+)
+
+// fakeClock is a manually advanced time source for cache tests.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+// upstream describes a JSON server for fetch tests. The zero value
+// answers {"ok": true} at once to every request.
+type upstream struct {
+	// Delay holds back every response.
+	Delay time.Duration
+	// Fail reports whether hit n, counting from 1, fails with Status and
+	// RetryAfter; nil never fails.
+	Fail       func(n int32) bool
+	Status     int
+	RetryAfter string
+	// Body renders the response to hit n; nil serves {"ok": true}.
+	Body func(n int32) []byte
+}
+
+// serve starts the server, returning it with its hit counter.
+func (u upstream) serve(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		time.Sleep(u.Delay)
+		if u.Fail != nil && u.Fail(n) {
+			if u.RetryAfter != "" {
+				w.Header().Set("Retry-After", u.RetryAfter)
+			}
+			http.Error(w, "unavailable", u.Status)
+			return
+		}
+		body := []byte(`{"ok": true}`)
+		if u.Body != nil {
+			body = u.Body(n)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// numbered serves a 30 s GBFS ttl and the hit number.
+func numbered(n int32) []byte { return []byte(fmt.Sprintf(`{"ttl": 30, "n": %d}`, n)) }
+
+func TestGetJSONCached_FreshAndStale(t *testing.T) {
+	srv, hits := upstream{Body: numbered}.serve(t)
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	fc := NewFetchController()
+	fc.now = clock.Now
+
+	policy := CachePolicy{TTL: time.Hour, MaxStale: time.Minute, TTLFromBody: gbfs.FreshFor}
+	var out struct {
+		N int `json:"n"`
+	}
+
+	if _, err := fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	clock.Advance(10 * time.Second)
+	meta, err := fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out)
+	if err != nil {
+		t.Fatalf("cached fetch: %v", err)
+	}
+	if atomic.LoadInt32(hits) != 1 || out.N != 1 || meta.Stale || meta.Age != 10*time.Second {
+		t.Fatalf("expected fresh cache hit: hits=%d n=%d meta=%+v", *hits, out.N, meta)
+	}
+
+	// Past the body ttl (30s) but within MaxStale: stale data, background refresh
+	clock.Advance(40 * time.Second)
+	meta, err = fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out)
+	if err != nil {
+		t.Fatalf("stale fetch: %v", err)
+	}
+	if !meta.Stale || out.N != 1 {
+		t.Fatalf("expected stale cached value: n=%d meta=%+v", out.N, meta)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(hits) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(hits) != 2 {
+		t.Fatalf("expected background refresh, hits=%d", *hits)
+	}
+}
+
+func TestGetJSONCached_ExpiredFetchesSynchronously(t *testing.T) {
+	srv, hits := upstream{Body: numbered}.serve(t)
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	fc := NewFetchController()
+	fc.now = clock.Now
+
+	policy := CachePolicy{TTL: 10 * time.Second, MaxStale: 10 * time.Second}
+	var out struct {
+		N int `json:"n"`
+	}
+	if _, err := fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	clock.Advance(time.Minute)
+	meta, err := fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out)
+	if err != nil {
+		t.Fatalf("second fetch: %v", err)
+	}
+	if atomic.LoadInt32(hits) != 2 || out.N != 2 || meta.Stale {
+		t.Fatalf("expected synchronous refetch: hits=%d n=%d meta=%+v", *hits, out.N, meta)
+	}
+}
+
+// A feed whose last_updated lags by more than its ttl is still cached for a
+// while instead of being refreshed on every request.
+func TestGetJSONCached_LaggingLastUpdated(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_000_000, 0)}
+	srv, hits := upstream{Body: func(int32) []byte {
+		return []byte(`{"ttl": 60, "last_updated": 999000}`)
+	}}.serve(t)
+	fc := NewFetchController()
+	fc.now = clock.Now
+
+	policy := CachePolicy{TTL: time.Hour, MaxStale: time.Minute, TTLFromBody: gbfs.FreshFor}
+	var out struct{}
+	if _, err := fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	clock.Advance(5 * time.Second)
+	meta, err := fc.GetJSONCached(context.Background(), srv.URL, nil, policy, &out)
+	if err != nil {
+		t.Fatalf("cached fetch: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond) // room for a background refresh, if any
+	if got := atomic.LoadInt32(hits); got != 1 || meta.Stale {
+		t.Fatalf("expected a fresh cache hit: hits=%d meta=%+v", got, meta)
+	}
+}
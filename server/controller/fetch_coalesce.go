@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// flightGroup coalesces identical in-flight GETs so concurrent callers
+// share one upstream round trip (singleflight-style).
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: map[string]*flightCall{}}
+}
+
+// flightKey identifies a request by URL and headers.
+func flightKey(fullURL string, headers map[string]string) string {
+	if len(headers) == 0 {
+		return fullURL
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		b.WriteString("\n" + k + ": " + headers[k])
+	}
+	return b.String()
+}
+
+// getShared returns the body for a GET, joining an identical request that is
+// already in flight. The shared request is detached from any single
+// caller's cancellation; each caller still stops waiting when its own
+// context ends.
+func (f *FetchController) getShared(ctx context.Context, fullURL string, headers map[string]string) ([]byte, error) {
+	key := flightKey(fullURL, headers)
+	g := f.inflight
+
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		c = &flightCall{done: make(chan struct{})}
+		g.calls[key] = c
+		go func() {
+			sctx, cancel := f.sharedContext(ctx)
+			defer cancel()
+			c.body, c.err = f.guardedFetch(sctx, fullURL, headers)
+
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.body, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sharedContext detaches ctx from its caller's cancellation and bounds it
+// by the client timeout instead.
+func (f *FetchController) sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	if f.Client.Timeout > 0 {
+		return context.WithTimeout(ctx, f.Client.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetJSON_CoalescesConcurrentRequests(t *testing.T) {
+	srv, hits := upstream{Delay: 100 * time.Millisecond}.serve(t)
+	fc := NewFetchController()
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var out struct {
+				OK bool `json:"ok"`
+			}
+			if err := fc.GetJSON(context.Background(), srv.URL, nil, &out); err != nil {
+				errs <- err
+				return
+			}
+			if !out.OK {
+				errs <- errors.New("unexpected body")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("GetJSON error: %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Fatalf("expected 1 upstream request, got %d", got)
+	}
+}
+
+func TestFetchWeather_CoalescesConcurrentHandlers(t *testing.T) {
+	body := minimalOneCall(1_000, 20, 50, nil)
+	srv, hits := upstream{Delay: 100 * time.Millisecond, Body: func(int32) []byte { return body }}.serve(t)
+
+	u, _ := url.Parse(srv.URL)
+	fc := NewFetchController()
+	fc.Client = &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &rewriteTransport{base: u, rt: http.DefaultTransport},
+	}
+	fc.Weather = &OpenWeatherProvider{APIKey: "testkey"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := FetchWeather(context.Background(), fc, 35.0, 139.0, MetricUnits, "ja"); err != nil {
+				t.Errorf("FetchWeather error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Fatalf("expected 1 upstream request, got %d", got)
+	}
+}
+
+func TestGetJSON_CallerCancelDoesNotFailOthers(t *testing.T) {
+	srv, hits := upstream{Delay: 100 * time.Millisecond}.serve(t)
+	fc := NewFetchController()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		var out struct{}
+		first <- fc.GetJSON(ctx, srv.URL, nil, &out)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	if err := fc.GetJSON(context.Background(), srv.URL, nil, &out); err != nil || !out.OK {
+		t.Fatalf("second caller failed: %v", err)
+	}
+	if err := <-first; err != context.Canceled {
+		t.Fatalf("expected first caller to be canceled, got %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Fatalf("expected 1 upstream request, got %d", got)
+	}
+}
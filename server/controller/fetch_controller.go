@@ -14,12 +14,30 @@ import (
 // for building URLs and decoding JSON. Other controllers should depend on it
 // to access external APIs.
 type FetchController struct {
-    Client *http.Client
+    Client  *http.Client
+    Retry   RetryPolicy
+    Breaker BreakerPolicy
+    // Weather serves the weather endpoints.
+    Weather WeatherProvider
+
+    cache    *responseCache
+    inflight *flightGroup
+    breakers *breakerSet
+    now      func() time.Time
 }
 
+// NewFetchController builds a controller with the default policies and
+// the weather providers configured in the environment.
 func NewFetchController() *FetchController {
     return &FetchController{
         Client: &http.Client{Timeout: 10 * time.Second},
+        Retry:    DefaultRetryPolicy,
+        Breaker:  DefaultBreakerPolicy,
+        Weather:  weatherProviderFromEnv(),
+        cache:    newResponseCache(),
+        inflight: newFlightGroup(),
+        breakers: newBreakerSet(),
+        now:      time.Now,
     }
 }
 
@@ -39,36 +57,61 @@ func (f *FetchController) BuildURL(base string, q map[string]string) (string, er
     return u.String(), nil
 }
 
+// redactURL drops the query string, which may carry API keys, for logging.
+func redactURL(raw string) string {
+    u, err := url.Parse(raw)
+    if err != nil {
+        return "<invalid url>"
+    }
+    u.RawQuery = ""
+    return u.String()
+}
+
 // HTTPError represents a non-2xx response from a remote server.
 type HTTPError struct {
     StatusCode int
     Body       string
+    // RetryAfter is the server's Retry-After hint, zero if absent.
+    RetryAfter time.Duration
 }
 
 func (e *HTTPError) Error() string { return fmt.Sprintf("remote error %d: %s", e.StatusCode, e.Body) }
 
 // GetJSON performs a GET request and decodes a JSON response into out.
 func (f *FetchController) GetJSON(ctx context.Context, fullURL string, headers map[string]string, out any) error {
-    req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+    body, err := f.getBody(ctx, fullURL, headers)
     if err != nil {
         return err
     }
+    // Allow unknown fields so partial structs can decode
+    return json.Unmarshal(body, out)
+}
+
+// fetchBody performs a single GET request and returns the raw 2xx response
+// body. Use getBody, which adds retries, request coalescing and circuit
+// breaking.
+func (f *FetchController) fetchBody(ctx context.Context, fullURL string, headers map[string]string) ([]byte, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+    if err != nil {
+        return nil, err
+    }
     for k, v := range headers {
         req.Header.Set(k, v)
     }
 
     resp, err := f.Client.Do(req)
     if err != nil {
-        return err
+        return nil, err
     }
     defer resp.Body.Close()
 
     if resp.StatusCode < 200 || resp.StatusCode >= 300 {
         b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-        return &HTTPError{StatusCode: resp.StatusCode, Body: string(b)}
+        return nil, &HTTPError{
+            StatusCode: resp.StatusCode,
+            Body:       string(b),
+            RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+        }
     }
-
-    dec := json.NewDecoder(resp.Body)
-    // Allow unknown fields so partial structs can decode
-    return dec.Decode(out)
+    return io.ReadAll(resp.Body)
 }
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures retries of transient upstream failures.
+type RetryPolicy struct {
+	// MaxAttempts includes the first try; values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles on
+	// each further attempt up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter in [0, 1] randomly shortens each delay by up to that fraction
+	// so that clients don't retry in lockstep.
+	Jitter float64
+}
+
+// DefaultRetryPolicy fits comfortably inside the handlers' 8s deadline.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      0.5,
+}
+
+// backoff returns the delay before attempt n+1, after n failed attempts.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// IsRetryable reports whether an upstream error is worth retrying:
+// throttling, gateway and availability errors, and transient network
+// failures. Client errors and caller cancellation are not.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var he *HTTPError
+	if errors.As(err, &he) {
+		switch he.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+			http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// parseRetryAfter accepts delay-seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// getBody returns the body for a GET, retrying transient failures with
+// exponential backoff. Retries stop early rather than sleep past the
+// caller's deadline.
+func (f *FetchController) getBody(ctx context.Context, fullURL string, headers map[string]string) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		body, err := f.getShared(ctx, fullURL, headers)
+		if err == nil || attempt >= f.Retry.MaxAttempts || !IsRetryable(err) {
+			return body, err
+		}
+
+		delay := f.Retry.backoff(attempt)
+		var he *HTTPError
+		if errors.As(err, &he) && he.RetryAfter > delay {
+			delay = he.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, err
+		}
+
+		log.Printf("[warn] retrying %s in %v (attempt %d/%d): %v", redactURL(fullURL), delay, attempt+1, f.Retry.MaxAttempts, err)
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		case <-t.C:
+		}
+	}
+}
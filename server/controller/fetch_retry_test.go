@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failFirst fails the first n requests.
+func failFirst(n int32) func(int32) bool { return func(hit int32) bool { return hit <= n } }
+
+func fastRetryFetch() *FetchController {
+	fc := NewFetchController()
+	fc.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Jitter: 0.5}
+	return fc
+}
+
+func TestGetJSON_RetriesTransientStatus(t *testing.T) {
+	srv, hits := upstream{Fail: failFirst(2), Status: http.StatusServiceUnavailable}.serve(t)
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	if err := fastRetryFetch().GetJSON(context.Background(), srv.URL, nil, &out); err != nil || !out.OK {
+		t.Fatalf("expected success after retries: %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestGetJSON_DoesNotRetryClientError(t *testing.T) {
+	srv, hits := upstream{Fail: failFirst(5), Status: http.StatusNotFound}.serve(t)
+	var out struct{}
+	err := fastRetryFetch().GetJSON(context.Background(), srv.URL, nil, &out)
+	if he, ok := err.(*HTTPError); !ok || he.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 HTTPError, got %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestGetJSON_RetryAfterBeyondDeadlineGivesUp(t *testing.T) {
+	srv, hits := upstream{Fail: failFirst(5), Status: http.StatusTooManyRequests, RetryAfter: "30"}.serve(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	var out struct{}
+	err := fastRetryFetch().GetJSON(ctx, srv.URL, nil, &out)
+	if he, ok := err.(*HTTPError); !ok || he.StatusCode != http.StatusTooManyRequests || he.RetryAfter != 30*time.Second {
+		t.Fatalf("expected 429 HTTPError with Retry-After, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("should give up without waiting, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(hits); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestRetryPolicy_BackoffCapped(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w {
+			t.Fatalf("backoff(%d): got %v want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestNewFetchController_RetriesByDefault(t *testing.T) {
+	srv, hits := upstream{Fail: failFirst(1), Status: http.StatusBadGateway}.serve(t)
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	if err := NewFetchController().GetJSON(context.Background(), srv.URL, nil, &out); err != nil || !out.OK {
+		t.Fatalf("expected the default policy to retry: %v", err)
+	}
+	if got := atomic.LoadInt32(hits); got != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got)
+	}
+}
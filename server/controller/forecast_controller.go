@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// forecastHours is how far ahead the hourly forecast reaches.
+const forecastHours = 12
+
+// ConditionDTO is a weather condition: OpenWeather's code, its group
+// (Rain, Clear, ...), a description in the requested language and an icon.
+// Other providers' conditions are mapped onto OpenWeather's codes.
+type ConditionDTO struct {
+	ID          int    `json:"id"`
+	Main        string `json:"main"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+func conditionOf(ws []OneCallWeather) *ConditionDTO {
+	if len(ws) == 0 {
+		return nil
+	}
+	w := ws[0]
+	return &ConditionDTO{ID: w.ID, Main: w.Main, Description: w.Description, Icon: w.Icon}
+}
+
+// HourlyDTO is one forecast hour. The suffixed fields are always metric;
+// Temperature, FeelsLike, WindSpeed and WindGust repeat them in the
+// forecast's Units.
+type HourlyDTO struct {
+	Time            time.Time `json:"time"`
+	TemperatureC    float64   `json:"temperatureC"`
+	FeelsLikeC      float64   `json:"feelsLikeC"`
+	HumidityPercent int       `json:"humidityPercent"`
+	UVIndex         float64   `json:"uvIndex"`
+	WindSpeedMS     float64   `json:"windSpeedMs"`
+	WindGustMS      float64   `json:"windGustMs"`
+	WindDeg         int       `json:"windDeg"`
+	// PrecipProbability is 0-1; PrecipMM sums rain and snow for the hour.
+	PrecipProbability float64       `json:"precipProbability"`
+	PrecipMM          float64       `json:"precipMm"`
+	Condition         *ConditionDTO `json:"condition,omitempty"`
+	Temperature       float64       `json:"temperature"`
+	FeelsLike         float64       `json:"feelsLike"`
+	WindSpeed         float64       `json:"windSpeed"`
+	WindGust          float64       `json:"windGust"`
+}
+
+// DailyDTO summarizes one forecast day. TempMin, TempMax and WindSpeed
+// repeat the metric fields in the forecast's Units.
+type DailyDTO struct {
+	Date              time.Time     `json:"date"`
+	Summary           string        `json:"summary,omitempty"`
+	TempMinC          float64       `json:"tempMinC"`
+	TempMaxC          float64       `json:"tempMaxC"`
+	PrecipProbability float64       `json:"precipProbability"`
+	PrecipMM          float64       `json:"precipMm"`
+	UVIndex           float64       `json:"uvIndex"`
+	WindSpeedMS       float64       `json:"windSpeedMs"`
+	Sunrise           time.Time     `json:"sunrise"`
+	Sunset            time.Time     `json:"sunset"`
+	Condition         *ConditionDTO `json:"condition,omitempty"`
+	TempMin           float64       `json:"tempMin"`
+	TempMax           float64       `json:"tempMax"`
+	WindSpeed         float64       `json:"windSpeed"`
+}
+
+// AlertDTO is a government weather alert.
+type AlertDTO struct {
+	Sender      string    `json:"sender"`
+	Event       string    `json:"event"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags,omitempty"`
+}
+
+// ForecastDTO is the normalized forecast served by /api/weather/forecast.
+type ForecastDTO struct {
+	Timezone string      `json:"timezone"`
+	Sunrise  time.Time   `json:"sunrise"`
+	Sunset   time.Time   `json:"sunset"`
+	Hourly   []HourlyDTO `json:"hourly"`
+	Daily    []DailyDTO  `json:"daily"`
+	// Alerts lists alerts that have not ended yet.
+	Alerts []AlertDTO `json:"alerts"`
+	Units  Units      `json:"units"`
+	// Provider is the ID of the provider that served the data.
+	Provider string `json:"provider"`
+	DataAge  `json:"-"`
+}
+
+func unix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// FetchForecast retrieves the forecast from the configured provider, with
+// display values in units.
+func FetchForecast(ctx context.Context, f *FetchController, lat, lon float64, units Units, lang string) (ForecastDTO, error) {
+	p := f.Weather
+	fc, err := p.Forecast(ctx, f, lat, lon, lang)
+	if err != nil {
+		log.Printf("FetchForecast: %s error: %v", p.ID(), err)
+		return ForecastDTO{}, err
+	}
+	return fc.In(units), nil
+}
+
+// newForecastDTO normalizes One Call's hourly, daily and alert sections.
+func newForecastDTO(oc OneCallResponse, meta FetchMeta) ForecastDTO {
+	out := ForecastDTO{
+		Timezone: oc.Timezone,
+		Sunrise:  unix(oc.Current.Sunrise),
+		Sunset:   unix(oc.Current.Sunset),
+		Hourly:   []HourlyDTO{},
+		Daily:    []DailyDTO{},
+		Alerts:   []AlertDTO{},
+		DataAge:  dataAge(meta),
+	}
+
+	// Hourly entries start at the top of the current hour.
+	for _, h := range oc.Hourly {
+		if h.Dt+3600 <= oc.Current.Dt {
+			continue
+		}
+		if len(out.Hourly) == forecastHours {
+			break
+		}
+		out.Hourly = append(out.Hourly, HourlyDTO{
+			Time:              unix(h.Dt),
+			TemperatureC:      h.Temp,
+			FeelsLikeC:        h.FeelsLike,
+			HumidityPercent:   h.Humidity,
+			UVIndex:           h.UVI,
+			WindSpeedMS:       h.WindSpeed,
+			WindGustMS:        h.WindGust,
+			WindDeg:           h.WindDeg,
+			PrecipProbability: h.Pop,
+			PrecipMM:          h.Rain.mm() + h.Snow.mm(),
+			Condition:         conditionOf(h.Weather),
+		})
+	}
+
+	for _, d := range oc.Daily {
+		out.Daily = append(out.Daily, DailyDTO{
+			Date:              unix(d.Dt),
+			Summary:           d.Summary,
+			TempMinC:          d.Temp.Min,
+			TempMaxC:          d.Temp.Max,
+			PrecipProbability: d.Pop,
+			PrecipMM:          d.Rain + d.Snow,
+			UVIndex:           d.UVI,
+			WindSpeedMS:       d.WindSpeed,
+			Sunrise:           unix(d.Sunrise),
+			Sunset:            unix(d.Sunset),
+			Condition:         conditionOf(d.Weather),
+		})
+	}
+
+	for _, a := range oc.Alerts {
+		if a.End != 0 && a.End <= oc.Current.Dt {
+			continue
+		}
+		out.Alerts = append(out.Alerts, AlertDTO{
+			Sender:      a.SenderName,
+			Event:       a.Event,
+			Start:       unix(a.Start),
+			End:         unix(a.End),
+			Description: a.Description,
+			Tags:        a.Tags,
+		})
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewForecastDTO(t *testing.T) {
+	// Current time is 30 minutes into the first hourly entry.
+	var hourly []string
+	for i := 0; i < 48; i++ {
+		dt := 36_000 + i*3600
+		hourly = append(hourly, fmt.Sprintf(`{"dt": %d, "temp": %d, "pop": 0.5, "rain": {"1h": 1.5}, "snow": {"1h": 0.5},
+			"weather": [{"id": 500, "main": "Rain", "description": "小雨", "icon": "10d"}]}`, dt, i))
+	}
+	body := `{
+		"timezone": "Asia/Tokyo",
+		"current": {"dt": 37800, "sunrise": 30000, "sunset": 70000},
+		"hourly": [` + strings.Join(hourly, ",") + `],
+		"daily": [{"dt": 40000, "summary": "Rain in the morning", "temp": {"min": 12, "max": 19}, "pop": 0.8, "rain": 6.5, "weather": [{"id": 501, "main": "Rain"}]}],
+		"alerts": [
+			{"sender_name": "JMA", "event": "大雨注意報", "start": 30000, "end": 80000, "tags": ["Rain"]},
+			{"sender_name": "JMA", "event": "強風注意報", "start": 10000, "end": 20000}
+		]
+	}`
+	var oc OneCallResponse
+	if err := json.Unmarshal([]byte(body), &oc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	fc := newForecastDTO(oc, FetchMeta{})
+	if len(fc.Hourly) != forecastHours {
+		t.Fatalf("expected %d hours, got %d", forecastHours, len(fc.Hourly))
+	}
+	h := fc.Hourly[0]
+	if !h.Time.Equal(time.Unix(36_000, 0)) || h.PrecipMM != 2 || h.PrecipProbability != 0.5 || h.Condition == nil || h.Condition.ID != 500 {
+		t.Fatalf("unexpected first hour: %+v", h)
+	}
+	if fc.Hourly[forecastHours-1].TemperatureC != forecastHours-1 {
+		t.Fatalf("unexpected last hour: %+v", fc.Hourly[forecastHours-1])
+	}
+	if len(fc.Daily) != 1 || fc.Daily[0].TempMaxC != 19 || fc.Daily[0].PrecipMM != 6.5 || fc.Daily[0].Summary == "" {
+		t.Fatalf("unexpected daily: %+v", fc.Daily)
+	}
+	if !fc.Sunrise.Equal(time.Unix(30000, 0)) || !fc.Sunset.Equal(time.Unix(70000, 0)) {
+		t.Fatalf("unexpected sunrise/sunset: %v %v", fc.Sunrise, fc.Sunset)
+	}
+	// The expired wind alert is dropped.
+	if len(fc.Alerts) != 1 || fc.Alerts[0].Event != "大雨注意報" {
+		t.Fatalf("unexpected alerts: %+v", fc.Alerts)
+	}
+}
@@ -0,0 +1,251 @@
+// Package gbfs reads General Bikeshare Feed Specification feeds. A Client
+// starts from an operator's gbfs.json discovery file and resolves the other
+// feeds by name and language. Both the 2.x and 3.0 schemas are decoded into
+// the same types.
+package gbfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Feed names used by the server.
+const (
+	FeedStationInformation = "station_information"
+	FeedStationStatus      = "station_status"
+	FeedVehicleTypes       = "vehicle_types"
+	// FeedVehicleStatus is the 3.0 name of 2.x's FeedFreeBikeStatus.
+	FeedVehicleStatus  = "vehicle_status"
+	FeedFreeBikeStatus = "free_bike_status"
+)
+
+// Timestamp is a GBFS time: POSIX seconds in 1.x/2.x, RFC 3339 in 3.0.
+type Timestamp struct{ time.Time }
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = Timestamp{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		*t = Timestamp{v}
+		return nil
+	}
+	sec, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("gbfs: bad timestamp %s", data)
+	}
+	if sec <= 0 {
+		*t = Timestamp{}
+		return nil
+	}
+	*t = Timestamp{time.Unix(sec, 0)}
+	return nil
+}
+
+// Flag is a GBFS boolean: true/false in 2.x+, 1/0 in 1.x. Set reports
+// whether the feed carried it at all.
+type Flag struct{ Set, Value bool }
+
+func (b *Flag) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = Flag{Set: true, Value: true}
+	case "false", "0":
+		*b = Flag{Set: true, Value: false}
+	case "null":
+		*b = Flag{}
+	default:
+		return fmt.Errorf("gbfs: bad boolean %s", data)
+	}
+	return nil
+}
+
+// Or returns the flag's value, or def when the feed omitted it.
+func (b Flag) Or(def bool) bool {
+	if !b.Set {
+		return def
+	}
+	return b.Value
+}
+
+// LocalizedString is a plain string in 2.x and a list of translations in 3.0.
+type LocalizedString []Translation
+
+// Translation is one language of a LocalizedString.
+type Translation struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+}
+
+func (l *LocalizedString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*l = LocalizedString{{Text: s}}
+		return nil
+	}
+	var ts []Translation
+	if err := json.Unmarshal(data, &ts); err != nil {
+		return err
+	}
+	*l = ts
+	return nil
+}
+
+// In returns the text for lang, falling back to the first translation.
+func (l LocalizedString) In(lang string) string {
+	for _, t := range l {
+		if t.Language == lang {
+			return t.Text
+		}
+	}
+	if len(l) > 0 {
+		return l[0].Text
+	}
+	return ""
+}
+
+// Header is the envelope every GBFS feed shares.
+type Header struct {
+	LastUpdated Timestamp `json:"last_updated"`
+	TTL         *int      `json:"ttl"`
+	Version     string    `json:"version"`
+}
+
+// minFresh is the shortest time FreshFor keeps a payload current. Many
+// publishers' last_updated lags by more than the ttl; without a floor each
+// of their payloads would be stale as soon as it is fetched.
+const minFresh = 15 * time.Second
+
+// FreshFor reads a feed's header and returns how long after fetchedAt the
+// payload stays current: ttl seconds from last_updated, at most ttl so a
+// publisher clock running ahead cannot extend it, and at least the smaller
+// of ttl and 15 seconds. ok is false when the feed carries no usable ttl.
+func FreshFor(body []byte, fetchedAt time.Time) (time.Duration, bool) {
+	var h Header
+	if err := json.Unmarshal(body, &h); err != nil || h.TTL == nil || *h.TTL < 0 {
+		return 0, false
+	}
+	ttl := time.Duration(*h.TTL) * time.Second
+	if h.LastUpdated.IsZero() {
+		return ttl, true
+	}
+	d := h.LastUpdated.Add(ttl).Sub(fetchedAt)
+	return max(min(d, ttl), min(ttl, minFresh)), true
+}
+
+// FeedRef is one entry of the discovery file.
+type FeedRef struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Discovery is a decoded gbfs.json. 2.x lists feeds per language; 3.0
+// has a single language-independent list, stored under the "" key.
+type Discovery struct {
+	Header
+	Feeds map[string][]FeedRef
+}
+
+func (d *Discovery) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Header
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	d.Header = raw.Header
+
+	var v3 struct {
+		Feeds []FeedRef `json:"feeds"`
+	}
+	if err := json.Unmarshal(raw.Data, &v3); err == nil && v3.Feeds != nil {
+		d.Feeds = map[string][]FeedRef{"": v3.Feeds}
+		return nil
+	}
+	var v2 map[string]struct {
+		Feeds []FeedRef `json:"feeds"`
+	}
+	if err := json.Unmarshal(raw.Data, &v2); err != nil {
+		return fmt.Errorf("gbfs: unrecognized discovery data: %w", err)
+	}
+	d.Feeds = make(map[string][]FeedRef, len(v2))
+	for lang, l := range v2 {
+		d.Feeds[lang] = l.Feeds
+	}
+	return nil
+}
+
+// FeedURL resolves a feed by name, preferring lang and otherwise taking
+// the first language in sorted order.
+func (d *Discovery) FeedURL(name, lang string) (string, bool) {
+	langs := make([]string, 0, len(d.Feeds))
+	for l := range d.Feeds {
+		if l != lang {
+			langs = append(langs, l)
+		}
+	}
+	sort.Strings(langs)
+	if _, ok := d.Feeds[lang]; ok {
+		langs = append([]string{lang}, langs...)
+	}
+	for _, l := range langs {
+		for _, f := range d.Feeds[l] {
+			if f.Name == name {
+				return f.URL, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Getter fetches url and decodes its JSON body into out. Callers plug in
+// their own HTTP stack, typically with caching honoring FreshFor.
+type Getter func(ctx context.Context, url string, out any) error
+
+// Client resolves feeds from one operator's discovery file.
+type Client struct {
+	DiscoveryURL string
+	// Language selects among 2.x per-language feed lists.
+	Language string
+	Get      Getter
+}
+
+// Discover fetches the discovery file.
+func (c *Client) Discover(ctx context.Context) (*Discovery, error) {
+	var d Discovery
+	if err := c.Get(ctx, c.DiscoveryURL, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// FeedURL fetches the discovery file and resolves a feed by name.
+func (c *Client) FeedURL(ctx context.Context, name string) (string, error) {
+	d, err := c.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, ok := d.FeedURL(name, c.Language)
+	if !ok {
+		return "", fmt.Errorf("gbfs: %s does not list %s", c.DiscoveryURL, name)
+	}
+	return u, nil
+}
@@ -0,0 +1,160 @@
+package gbfs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+const discoveryV2 = `{"last_updated": 1700000000, "ttl": 60, "version": "2.3", "data": {
+	"en": {"feeds": [{"name": "station_status", "url": "https://x/en/station_status.json"}]},
+	"ja": {"feeds": [{"name": "station_status", "url": "https://x/ja/station_status.json"},
+	                 {"name": "station_information", "url": "https://x/ja/station_information.json"}]}
+}}`
+
+const discoveryV3 = `{"last_updated": "2023-11-14T22:13:20Z", "ttl": 0, "version": "3.0", "data": {
+	"feeds": [{"name": "station_status", "url": "https://y/station_status.json"}]
+}}`
+
+func TestDiscovery_FeedURL(t *testing.T) {
+	var v2 Discovery
+	if err := json.Unmarshal([]byte(discoveryV2), &v2); err != nil {
+		t.Fatalf("v2 decode: %v", err)
+	}
+	if u, _ := v2.FeedURL(FeedStationStatus, "ja"); u != "https://x/ja/station_status.json" {
+		t.Fatalf("preferred language not used: %q", u)
+	}
+	// Unknown language falls back to the first in sorted order.
+	if u, _ := v2.FeedURL(FeedStationStatus, "fr"); u != "https://x/en/station_status.json" {
+		t.Fatalf("unexpected fallback: %q", u)
+	}
+	// Feeds missing in the preferred language come from another one.
+	if u, ok := v2.FeedURL(FeedStationInformation, "en"); !ok || u != "https://x/ja/station_information.json" {
+		t.Fatalf("unexpected cross-language lookup: %q", u)
+	}
+
+	var v3 Discovery
+	if err := json.Unmarshal([]byte(discoveryV3), &v3); err != nil {
+		t.Fatalf("v3 decode: %v", err)
+	}
+	if v3.Version != "3.0" || !v3.LastUpdated.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("unexpected v3 header: %+v", v3.Header)
+	}
+	if u, ok := v3.FeedURL(FeedStationStatus, "ja"); !ok || u != "https://y/station_status.json" {
+		t.Fatalf("unexpected v3 url: %q", u)
+	}
+	if _, ok := v3.FeedURL(FeedStationInformation, "ja"); ok {
+		t.Fatalf("unlisted feed should not resolve")
+	}
+}
+
+func TestClient_FeedURL(t *testing.T) {
+	var fetched string
+	c := &Client{
+		DiscoveryURL: "https://x/gbfs.json",
+		Language:     "ja",
+		Get: func(ctx context.Context, url string, out any) error {
+			fetched = url
+			return json.Unmarshal([]byte(discoveryV2), out)
+		},
+	}
+	u, err := c.FeedURL(context.Background(), FeedStationInformation)
+	if err != nil || u != "https://x/ja/station_information.json" || fetched != c.DiscoveryURL {
+		t.Fatalf("unexpected resolution: url=%q err=%v fetched=%q", u, err, fetched)
+	}
+	if _, err := c.FeedURL(context.Background(), "vehicle_types"); err == nil {
+		t.Fatalf("expected error for unlisted feed")
+	}
+}
+
+func TestStationFeeds_V2AndV3(t *testing.T) {
+	v2 := `{"data": {"stations": [{"station_id": "1", "num_bikes_available": 3, "num_bikes_disabled": 1,
+		"num_docks_available": 4, "is_installed": 1, "is_renting": 0, "last_reported": 1700000000}]}}`
+	v3 := `{"data": {"stations": [{"station_id": "1", "num_vehicles_available": 3, "num_vehicles_disabled": 1,
+		"is_installed": true, "is_renting": false, "last_reported": "2023-11-14T22:13:20Z"}]}}`
+	for name, body := range map[string]string{"v2": v2, "v3": v3} {
+		var st StationStatusFeed
+		if err := json.Unmarshal([]byte(body), &st); err != nil {
+			t.Fatalf("%s decode: %v", name, err)
+		}
+		s := st.Data.Stations[0]
+		if s.StationID != "1" || s.NumBikesAvailable != 3 || s.NumBikesDisabled != 1 {
+			t.Fatalf("%s: unexpected counts: %+v", name, s)
+		}
+		if !s.IsInstalled.Or(false) || s.IsRenting.Or(true) || !s.IsReturning.Or(true) {
+			t.Fatalf("%s: unexpected flags: %+v", name, s)
+		}
+		if !s.LastReported.Equal(time.Unix(1700000000, 0)) {
+			t.Fatalf("%s: unexpected last_reported: %v", name, s.LastReported)
+		}
+	}
+
+	var info StationInformation
+	body := `{"data": {"stations": [
+		{"station_id": "1", "name": "駅前", "capacity": 8},
+		{"station_id": "2", "name": [{"text": "Park", "language": "en"}, {"text": "公園", "language": "ja"}]}
+	]}}`
+	if err := json.Unmarshal([]byte(body), &info); err != nil {
+		t.Fatalf("info decode: %v", err)
+	}
+	if got := info.Data.Stations[0].Name.In("ja"); got != "駅前" {
+		t.Fatalf("unexpected v2 name: %q", got)
+	}
+	if got := info.Data.Stations[1].Name.In("ja"); got != "公園" {
+		t.Fatalf("unexpected v3 name: %q", got)
+	}
+}
+
+func TestFreshFor(t *testing.T) {
+	fetched := time.Unix(1700000030, 0)
+	cases := []struct {
+		body string
+		want time.Duration
+		ok   bool
+	}{
+		{`{"ttl": 60}`, 60 * time.Second, true},
+		{`{"ttl": 60, "last_updated": 1700000000}`, 30 * time.Second, true},
+		{`{"ttl": 60, "last_updated": "2023-11-14T22:13:20Z"}`, 30 * time.Second, true},
+		// Lagging publisher: already expired, so the floor applies.
+		{`{"ttl": 60, "last_updated": 1699990000}`, 15 * time.Second, true},
+		{`{"ttl": 60, "last_updated": 1700000020}`, 50 * time.Second, true},
+		{`{"ttl": 60, "last_updated": 1699999975}`, 15 * time.Second, true},
+		// A ttl below the floor is kept; 0 means always refetch.
+		{`{"ttl": 10, "last_updated": 1699990000}`, 10 * time.Second, true},
+		{`{"ttl": 0, "last_updated": 1699990000}`, 0, true},
+		// Publisher clock ahead: never longer than ttl.
+		{`{"ttl": 60, "last_updated": 1700009999}`, 60 * time.Second, true},
+		{`{"last_updated": 1700000000}`, 0, false},
+	}
+	for _, c := range cases {
+		got, ok := FreshFor([]byte(c.body), fetched)
+		if got != c.want || ok != c.ok {
+			t.Fatalf("FreshFor(%s) = %v,%v want %v,%v", c.body, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestVehicleStatusFeed_V2AndV3(t *testing.T) {
+	v2 := `{"data": {"bikes": [{"bike_id": "b1", "station_id": "1", "vehicle_type_id": "e", "current_range_meters": 4200}]}}`
+	v3 := `{"data": {"vehicles": [{"vehicle_id": "b1", "station_id": "1", "vehicle_type_id": "e", "current_fuel_percent": 0.5}]}}`
+	ebike := VehicleType{VehicleTypeID: "e", PropulsionType: PropulsionElectricAssist, MaxRangeMeters: 8400}
+	for name, body := range map[string]string{"v2": v2, "v3": v3} {
+		var vs VehicleStatusFeed
+		if err := json.Unmarshal([]byte(body), &vs); err != nil {
+			t.Fatalf("%s decode: %v", name, err)
+		}
+		if len(vs.Vehicles) != 1 || vs.Vehicles[0].ID != "b1" || vs.Vehicles[0].StationID != "1" {
+			t.Fatalf("%s: unexpected vehicles: %+v", name, vs.Vehicles)
+		}
+		if r, ok := vs.Vehicles[0].RangeMeters(ebike); !ok || r != 4200 {
+			t.Fatalf("%s: unexpected range %v,%v", name, r, ok)
+		}
+	}
+	if _, ok := (Vehicle{}).RangeMeters(ebike); ok {
+		t.Fatalf("range without data should be unknown")
+	}
+	if !ebike.Battery() || (VehicleType{PropulsionType: "human"}).Battery() {
+		t.Fatalf("unexpected battery classification")
+	}
+}
@@ -0,0 +1,30 @@
+// Package gbfstest serves GBFS feeds for tests.
+package gbfstest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// NewServer serves a 2.x discovery file at /gbfs.json listing a single
+// station_status feed with the given body; other paths are not found.
+// The server is closed when the test ends.
+func NewServer(t testing.TB, status string) *httptest.Server {
+	t.Helper()
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/gbfs.json":
+			_, _ = w.Write([]byte(`{"ttl": 60, "data": {"ja": {"feeds": [{"name": "station_status", "url": "` + srvURL + `/station_status.json"}]}}}`))
+		case "/station_status.json":
+			_, _ = w.Write([]byte(status))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	srvURL = srv.URL
+	return srv
+}
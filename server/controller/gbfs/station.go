@@ -0,0 +1,88 @@
+package gbfs
+
+import "encoding/json"
+
+// StationInformation is a decoded station_information feed.
+type StationInformation struct {
+	Header
+	Data struct {
+		Stations []StationInfo `json:"stations"`
+	} `json:"data"`
+}
+
+// StationInfo is the static description of one station.
+type StationInfo struct {
+	StationID string          `json:"station_id"`
+	Name      LocalizedString `json:"name"`
+	Lat       float64         `json:"lat"`
+	Lon       float64         `json:"lon"`
+	Capacity  int             `json:"capacity"`
+}
+
+// StationStatusFeed is a decoded station_status feed.
+type StationStatusFeed struct {
+	Header
+	Data struct {
+		Stations []StationStatus `json:"stations"`
+	} `json:"data"`
+}
+
+// StationStatus is the live state of one station. 3.0 renamed the bike
+// counts to num_vehicles_*; both spellings decode into the same fields.
+type StationStatus struct {
+	StationID         string
+	NumBikesAvailable int
+	NumBikesDisabled  int
+	// NumDocksAvailable is nil when the feed omits it (optional for
+	// stations with unlimited docking).
+	NumDocksAvailable *int
+	IsInstalled       Flag
+	IsRenting         Flag
+	IsReturning       Flag
+	LastReported      Timestamp
+	// VehicleTypesAvailable breaks NumBikesAvailable down by type, when
+	// the operator publishes vehicle_types.
+	VehicleTypesAvailable []VehicleTypeCount
+}
+
+func (s *StationStatus) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		StationID            string    `json:"station_id"`
+		NumBikesAvailable    *int      `json:"num_bikes_available"`
+		NumVehiclesAvailable *int      `json:"num_vehicles_available"`
+		NumBikesDisabled     *int      `json:"num_bikes_disabled"`
+		NumVehiclesDisabled  *int      `json:"num_vehicles_disabled"`
+		NumDocksAvailable    *int      `json:"num_docks_available"`
+		IsInstalled          Flag      `json:"is_installed"`
+		IsRenting            Flag      `json:"is_renting"`
+		IsReturning          Flag      `json:"is_returning"`
+		LastReported         Timestamp `json:"last_reported"`
+
+		VehicleTypesAvailable []VehicleTypeCount `json:"vehicle_types_available"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = StationStatus{
+		StationID:         raw.StationID,
+		NumBikesAvailable: firstInt(raw.NumBikesAvailable, raw.NumVehiclesAvailable),
+		NumBikesDisabled:  firstInt(raw.NumBikesDisabled, raw.NumVehiclesDisabled),
+		NumDocksAvailable: raw.NumDocksAvailable,
+		IsInstalled:       raw.IsInstalled,
+		IsRenting:         raw.IsRenting,
+		IsReturning:       raw.IsReturning,
+		LastReported:      raw.LastReported,
+
+		VehicleTypesAvailable: raw.VehicleTypesAvailable,
+	}
+	return nil
+}
+
+func firstInt(vs ...*int) int {
+	for _, v := range vs {
+		if v != nil {
+			return *v
+		}
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package gbfs
+
+import "encoding/json"
+
+// Propulsion types that run on a battery.
+const (
+	PropulsionElectricAssist = "electric_assist"
+	PropulsionElectric       = "electric"
+)
+
+// VehicleTypesFeed is a decoded vehicle_types feed.
+type VehicleTypesFeed struct {
+	Header
+	Data struct {
+		VehicleTypes []VehicleType `json:"vehicle_types"`
+	} `json:"data"`
+}
+
+// VehicleType describes one kind of vehicle an operator rents out.
+type VehicleType struct {
+	VehicleTypeID  string          `json:"vehicle_type_id"`
+	FormFactor     string          `json:"form_factor"`
+	PropulsionType string          `json:"propulsion_type"`
+	Name           LocalizedString `json:"name"`
+	// MaxRangeMeters is the range on a full charge; zero if unknown.
+	MaxRangeMeters float64 `json:"max_range_meters"`
+}
+
+// Battery reports whether the vehicle type needs charge to be useful.
+func (t VehicleType) Battery() bool {
+	return t.PropulsionType == PropulsionElectricAssist || t.PropulsionType == PropulsionElectric
+}
+
+// VehicleTypeCount is one entry of a station's vehicle_types_available.
+type VehicleTypeCount struct {
+	VehicleTypeID string `json:"vehicle_type_id"`
+	Count         int    `json:"count"`
+}
+
+// VehicleStatusFeed is a decoded vehicle_status (3.0) or free_bike_status
+// (2.x) feed; the list is named vehicles and bikes respectively.
+type VehicleStatusFeed struct {
+	Header
+	Vehicles []Vehicle
+}
+
+func (v *VehicleStatusFeed) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Header
+		Data struct {
+			Vehicles []Vehicle `json:"vehicles"`
+			Bikes    []Vehicle `json:"bikes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	v.Header = raw.Header
+	v.Vehicles = raw.Data.Vehicles
+	if v.Vehicles == nil {
+		v.Vehicles = raw.Data.Bikes
+	}
+	return nil
+}
+
+// Vehicle is one vehicle's live state.
+type Vehicle struct {
+	// ID is vehicle_id in 3.0 and bike_id in 2.x.
+	ID            string
+	StationID     string
+	VehicleTypeID string
+	IsReserved    Flag
+	IsDisabled    Flag
+	// CurrentRangeMeters and CurrentFuelPercent (0-1) are nil when the
+	// feed omits them.
+	CurrentRangeMeters *float64
+	CurrentFuelPercent *float64
+}
+
+func (v *Vehicle) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		VehicleID          string   `json:"vehicle_id"`
+		BikeID             string   `json:"bike_id"`
+		StationID          string   `json:"station_id"`
+		VehicleTypeID      string   `json:"vehicle_type_id"`
+		IsReserved         Flag     `json:"is_reserved"`
+		IsDisabled         Flag     `json:"is_disabled"`
+		CurrentRangeMeters *float64 `json:"current_range_meters"`
+		CurrentFuelPercent *float64 `json:"current_fuel_percent"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*v = Vehicle{
+		ID:                 raw.VehicleID,
+		StationID:          raw.StationID,
+		VehicleTypeID:      raw.VehicleTypeID,
+		IsReserved:         raw.IsReserved,
+		IsDisabled:         raw.IsDisabled,
+		CurrentRangeMeters: raw.CurrentRangeMeters,
+		CurrentFuelPercent: raw.CurrentFuelPercent,
+	}
+	if v.ID == "" {
+		v.ID = raw.BikeID
+	}
+	return nil
+}
+
+// RangeMeters estimates the vehicle's remaining range from
+// current_range_meters, or from current_fuel_percent and the type's
+// max_range_meters. ok is false when neither is available.
+func (v Vehicle) RangeMeters(t VehicleType) (float64, bool) {
+	if v.CurrentRangeMeters != nil {
+		return *v.CurrentRangeMeters, true
+	}
+	if v.CurrentFuelPercent != nil && t.MaxRangeMeters > 0 {
+		return *v.CurrentFuelPercent * t.MaxRangeMeters, true
+	}
+	return 0, false
+}
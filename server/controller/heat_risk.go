@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"math"
+	"time"
+)
+
+// Heat stroke risk levels of the Ministry of the Environment's guideline
+// for daily life, by WBGT (°C).
+const (
+	HeatSafe    = "safe"    // below 21: ほぼ安全
+	HeatCaution = "caution" // 21-25: 注意
+	HeatWarning = "warning" // 25-28: 警戒
+	HeatSevere  = "severe"  // 28-31: 厳重警戒
+	HeatDanger  = "danger"  // 31 and above: 危険
+)
+
+var heatLevels = []struct {
+	min          float64
+	level, label string
+}{
+	{31, HeatDanger, "危険"},
+	{28, HeatSevere, "厳重警戒"},
+	{25, HeatWarning, "警戒"},
+	{21, HeatCaution, "注意"},
+	{math.Inf(-1), HeatSafe, "ほぼ安全"},
+}
+
+// HeatRiskDTO is the estimated heat stress at the weather point.
+type HeatRiskDTO struct {
+	// WBGTC is an estimate from weather data, not a measurement.
+	WBGTC      float64 `json:"wbgtC"`
+	HeatIndexC float64 `json:"heatIndexC"`
+	Level      string  `json:"level"`
+	Label      string  `json:"label"`
+	// SolarKWM2 is the global solar radiation the estimate assumed.
+	SolarKWM2 float64 `json:"solarKwM2"`
+}
+
+// estimateHeatRisk estimates WBGT with the Ministry of the Environment's
+// formula for outdoor WBGT from temperature, humidity, wind and global
+// solar radiation (Ono & Tonouchi, 2014). Radiation is not in the feeds, so
+// it is modelled from the sun's elevation at the observation time and the
+// cloud cover. The UV index is not used to correct it: UV is a few percent
+// of the radiation, its share varies with sun elevation and ozone, and
+// clouds dim it less than visible light, so it would skew the estimate
+// under cloud. UV is scored on its own in the recommendation instead.
+func estimateHeatRisk(w WeatherDTO, lat, lon float64) *HeatRiskDTO {
+	if w.ObservedAt.IsZero() {
+		return nil
+	}
+	ta, rh := w.TemperatureC, float64(w.HumidityPercent)
+	sr := solarRadiation(lat, lon, w.ObservedAt, w.CloudsPercent)
+	wbgt := 0.735*ta + 0.0374*rh + 0.00292*ta*rh + 7.619*sr - 4.557*sr*sr - 0.0572*w.WindSpeedMS - 4.064
+
+	out := &HeatRiskDTO{
+		WBGTC:      math.Round(wbgt*10) / 10,
+		HeatIndexC: math.Round(heatIndex(ta, rh)*10) / 10,
+		SolarKWM2:  math.Round(sr*100) / 100,
+	}
+	for _, l := range heatLevels {
+		if out.WBGTC >= l.min {
+			out.Level, out.Label = l.level, l.label
+			break
+		}
+	}
+	return out
+}
+
+// solarRadiation is the global horizontal irradiance in kW/m²: Haurwitz's
+// clear-sky model reduced for cloud cover after Kasten and Czeplak.
+func solarRadiation(lat, lon float64, t time.Time, cloudsPercent int) float64 {
+	sinEl := sinSolarElevation(lat, lon, t)
+	if sinEl <= 0 {
+		return 0
+	}
+	clear := 1.098 * sinEl * math.Exp(-0.057/sinEl)
+	c := math.Min(1, math.Max(0, float64(cloudsPercent)/100))
+	return clear * (1 - 0.75*math.Pow(c, 3.4))
+}
+
+// sinSolarElevation approximates the sine of the sun's elevation; the
+// equation of time is ignored, which is a few minutes at most.
+func sinSolarElevation(lat, lon float64, t time.Time) float64 {
+	t = t.UTC()
+	rad := math.Pi / 180
+	decl := 23.44 * rad * math.Sin(2*math.Pi*float64(284+t.YearDay())/365)
+	solarHours := float64(t.Hour()) + float64(t.Minute())/60 + lon/15
+	hourAngle := (solarHours - 12) * 15 * rad
+	phi := lat * rad
+	return math.Sin(phi)*math.Sin(decl) + math.Cos(phi)*math.Cos(decl)*math.Cos(hourAngle)
+}
+
+// heatIndex is the US National Weather Service heat index in °C.
+func heatIndex(tc, rh float64) float64 {
+	t := tc*9/5 + 32
+	hi := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (hi+t)/2 >= 80 {
+		hi = -42.379 + 2.04901523*t + 10.14333127*rh - 0.22475541*t*rh -
+			0.00683783*t*t - 0.05481717*rh*rh + 0.00122874*t*t*rh +
+			0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+		switch {
+		case rh < 13 && t >= 80 && t <= 112:
+			hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+		case rh > 85 && t >= 80 && t <= 87:
+			hi += (rh - 85) / 10 * (87 - t) / 5
+		}
+	}
+	return (hi - 32) * 5 / 9
+}
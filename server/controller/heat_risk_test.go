@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateHeatRisk(t *testing.T) {
+	jst := time.FixedZone("JST", 9*3600)
+	const lat, lon = 35.8, 139.56
+	noon := time.Date(2026, 8, 1, 12, 0, 0, 0, jst)
+
+	for _, tc := range []struct {
+		name string
+		w    WeatherDTO
+		want string
+	}{
+		{"sunny midsummer noon", WeatherDTO{TemperatureC: 35, HumidityPercent: 60, WindSpeedMS: 2, ObservedAt: noon}, HeatDanger},
+		{"overcast humid afternoon", WeatherDTO{TemperatureC: 32, HumidityPercent: 70, WindSpeedMS: 3, CloudsPercent: 100, ObservedAt: noon.Add(3 * time.Hour)}, HeatSevere},
+		{"tropical night", WeatherDTO{TemperatureC: 27, HumidityPercent: 80, WindSpeedMS: 1, ObservedAt: noon.Add(11 * time.Hour)}, HeatWarning},
+		{"spring morning", WeatherDTO{TemperatureC: 15, HumidityPercent: 50, WindSpeedMS: 3, ObservedAt: noon.Add(-4 * time.Hour)}, HeatSafe},
+	} {
+		h := estimateHeatRisk(tc.w, lat, lon)
+		if h == nil || h.Level != tc.want {
+			t.Errorf("%s: got %+v want level %s", tc.name, h, tc.want)
+		}
+	}
+
+	if h := estimateHeatRisk(WeatherDTO{TemperatureC: 20, ObservedAt: noon.Add(12 * time.Hour)}, lat, lon); h.SolarKWM2 != 0 {
+		t.Errorf("expected no sun at midnight, got %.2f kW/m²", h.SolarKWM2)
+	}
+	if estimateHeatRisk(WeatherDTO{TemperatureC: 35}, lat, lon) != nil {
+		t.Errorf("expected no estimate without an observation time")
+	}
+	// 32°C at 70% is a heat index of about 41°C.
+	if hi := heatIndex(32, 70); hi < 40 || hi > 42 {
+		t.Errorf("unexpected heat index: %.1f", hi)
+	}
+}
@@ -0,0 +1,141 @@
+package history
+
+import (
+	"math"
+	"time"
+)
+
+// Forecast bases.
+const (
+	BasisProfile = "profile" // past days blended with today's trend
+	BasisTrend   = "trend"   // today's trend only
+	BasisCurrent = "current" // no history; current value held constant
+)
+
+// Forecast is a predicted group count at a future time.
+type Forecast struct {
+	At       time.Time `json:"at"`
+	Expected float64   `json:"expected"`
+	// Probability that at least one is available at At.
+	Probability float64 `json:"probability"`
+	// Samples is how many past days backed the profile.
+	Samples int    `json:"samples"`
+	Basis   string `json:"basis"`
+}
+
+// Forecaster predicts group availability from recorded history. For each
+// past day of the same class it takes the change between now's and the
+// target's time of day, applies it to the current value, and blends the
+// result with a linear extrapolation of today's last half hour.
+type Forecaster struct {
+	Store *Store
+	// DayClass groups days with similar patterns (e.g. bus day types). Nil,
+	// or an empty class for a day, falls back to WeekClass.
+	DayClass func(time.Time) string
+	// Window is the tolerance when matching a time of day on past days;
+	// zero means 15 minutes.
+	Window time.Duration
+}
+
+const (
+	trendWindow = 30 * time.Minute
+	// trendWeight is how many past days the trend estimate is worth.
+	trendWeight = 3.0
+)
+
+// Returnable forecasts a group's returnable docks at at, given its
+// current value. key is a GroupKey.
+func (f *Forecaster) Returnable(key string, now time.Time, current int, at time.Time) Forecast {
+	return f.forecast(key, now, current, at, func(c Counts) int { return c.Returnable })
+}
+
+func (f *Forecaster) forecast(key string, now time.Time, current int, at time.Time, val func(Counts) int) Forecast {
+	out := Forecast{At: at, Expected: float64(current), Basis: BasisCurrent}
+
+	// Today's trend.
+	trend := float64(current)
+	if recent := f.Store.GroupSeries(key, now.Add(-trendWindow), now); len(recent) > 0 {
+		first := recent[0]
+		if span := now.Sub(first.Time); span > time.Minute {
+			slope := float64(current-val(first.Counts)) / span.Seconds()
+			trend = math.Max(0, float64(current)+slope*at.Sub(now).Seconds())
+			out.Basis = BasisTrend
+		}
+	}
+
+	// Profile of past days of the same class.
+	window := f.Window
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+	class := f.dayClass(at)
+	var sum float64
+	hits := 0
+	for k := 1; k*24 <= int(f.Store.retention/time.Hour); k++ {
+		base, target := now.AddDate(0, 0, -k), at.AddDate(0, 0, -k)
+		if f.dayClass(target) != class {
+			continue
+		}
+		b, ok1 := f.nearest(key, base, window)
+		t, ok2 := f.nearest(key, target, window)
+		if !ok1 || !ok2 {
+			continue
+		}
+		pred := math.Max(0, float64(current+val(t)-val(b)))
+		sum += pred
+		if pred >= 1 {
+			hits++
+		}
+		out.Samples++
+	}
+
+	trendP := 1 - math.Exp(-trend) // Poisson P(X >= 1) around the trend
+	if out.Samples == 0 {
+		out.Expected, out.Probability = trend, trendP
+		return out
+	}
+	n := float64(out.Samples)
+	w := n / (n + trendWeight)
+	profileP := (float64(hits) + 1) / (n + 2) // Laplace-smoothed
+	out.Expected = w*(sum/n) + (1-w)*trend
+	out.Probability = w*profileP + (1-w)*trendP
+	out.Basis = BasisProfile
+	return out
+}
+
+func (f *Forecaster) dayClass(t time.Time) string {
+	if f.DayClass != nil {
+		if c := f.DayClass(t); c != "" {
+			return c
+		}
+	}
+	return WeekClass(t)
+}
+
+// WeekClass separates weekdays from weekends.
+func WeekClass(t time.Time) string {
+	switch t.Weekday() {
+	case time.Saturday, time.Sunday:
+		return "weekend"
+	}
+	return "weekday"
+}
+
+// nearest returns the group sample closest to t within window.
+func (f *Forecaster) nearest(key string, t time.Time, window time.Duration) (Counts, bool) {
+	var best Point
+	found := false
+	for _, p := range f.Store.GroupSeries(key, t.Add(-window), t.Add(window)) {
+		if !found || absDur(p.Time.Sub(t)) < absDur(best.Time.Sub(t)) {
+			best, found = p, true
+		}
+	}
+	return best.Counts, found
+}
+
+func absDur(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,84 @@
+package history
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestForecaster_ProfileDrainsDocks(t *testing.T) {
+	key := GroupKey("r", "campus")
+	// Thursday 08:00; on past weekdays the campus docks fill up by 08:20.
+	now := time.Date(2026, 4, 9, 8, 0, 0, 0, time.UTC)
+	st, err := Open(t.TempDir(), 14*24*time.Hour, now.AddDate(0, 0, -14))
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	weekdays := 0
+	for k := 13; k >= 1; k-- {
+		day := now.AddDate(0, 0, -k)
+		if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			continue
+		}
+		weekdays++
+		for _, s := range []struct {
+			off time.Duration
+			n   int
+		}{{0, 5}, {20 * time.Minute, 0}} {
+			snap := Snapshot{Time: day.Add(s.off), Groups: map[string]Counts{key: {Returnable: s.n}}}
+			if err := st.Append(snap); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	f := &Forecaster{Store: st}
+	fc := f.Returnable(key, now, 4, now.Add(20*time.Minute))
+	if fc.Basis != BasisProfile || fc.Samples != weekdays {
+		t.Fatalf("unexpected basis/samples: %+v (want %d weekdays)", fc, weekdays)
+	}
+	// Every past weekday ended with no docks; only the trend prior (current
+	// held at 4) keeps the probability above zero.
+	if fc.Probability > 0.5 || fc.Expected > 2 {
+		t.Fatalf("expected a pessimistic forecast, got %+v", fc)
+	}
+
+	// Weekends have no history here, so only the current value remains.
+	sat := time.Date(2026, 4, 11, 8, 0, 0, 0, time.UTC)
+	fc = f.Returnable(key, sat, 4, sat.Add(20*time.Minute))
+	if fc.Samples != 0 || math.Abs(fc.Probability-(1-math.Exp(-4))) > 1e-9 || fc.Expected != 4 {
+		t.Fatalf("unexpected forecast without matching days: %+v", fc)
+	}
+}
+
+func TestForecaster_Trend(t *testing.T) {
+	key := GroupKey("r", "campus")
+	now := time.Date(2026, 4, 9, 8, 0, 0, 0, time.UTC)
+	st, err := Open(t.TempDir(), 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	// Ten docks twenty minutes ago, six now: minus one every five minutes.
+	if err := st.Append(Snapshot{Time: now.Add(-20 * time.Minute), Groups: map[string]Counts{key: {Returnable: 10}}}); err != nil {
+		t.Fatal(err)
+	}
+	fc := (&Forecaster{Store: st}).Returnable(key, now, 6, now.Add(20*time.Minute))
+	if fc.Basis != BasisTrend || math.Abs(fc.Expected-2) > 1e-9 {
+		t.Fatalf("unexpected trend forecast: %+v", fc)
+	}
+	fc = (&Forecaster{Store: st}).Returnable(key, now, 6, now.Add(time.Hour))
+	if fc.Expected != 0 || fc.Probability != 0 {
+		t.Fatalf("trend should bottom out at zero: %+v", fc)
+	}
+}
+
+func TestForecaster_EmptyDayClassFallsBack(t *testing.T) {
+	f := &Forecaster{DayClass: func(time.Time) string { return "" }}
+	sat := time.Date(2026, 6, 6, 8, 0, 0, 0, time.UTC)
+	if got := f.dayClass(sat); got != "weekend" {
+		t.Fatalf("expected weekend fallback, got %q", got)
+	}
+	if got := f.dayClass(sat.AddDate(0, 0, 2)); got != "weekday" {
+		t.Fatalf("expected weekday fallback, got %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/bus"
+	"optimal-rion/server/controller/gbfs/gbfstest"
+	"optimal-rion/server/controller/route"
+)
+
+func snapAt(t time.Time, rentable int) Snapshot {
+	return Snapshot{
+		Time:     t,
+		Groups:   map[string]Counts{GroupKey("r", "station"): {Rentable: rentable}},
+		Stations: map[string]Counts{"1": {Rentable: rentable}},
+	}
+}
+
+func TestStore_AppendReopenAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+	st, err := Open(dir, 48*time.Hour, day)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	for i, at := range []time.Time{day, day.Add(time.Hour), day.Add(24 * time.Hour)} {
+		if err := st.Append(snapAt(at, i)); err != nil {
+			t.Fatalf("Append error: %v", err)
+		}
+	}
+	pts := st.GroupSeries(GroupKey("r", "station"), day.Add(30*time.Minute), day.Add(48*time.Hour))
+	if len(pts) != 2 || pts[0].Rentable != 1 || pts[1].Rentable != 2 {
+		t.Fatalf("unexpected group series: %+v", pts)
+	}
+	if pts := st.StationSeries("hellocycling:1", day, day); len(pts) != 1 {
+		t.Fatalf("prefixed default-operator ref not matched: %+v", pts)
+	}
+
+	// A torn line from a crash is skipped on reload.
+	f, _ := os.OpenFile(filepath.Join(dir, dayFile(day)), os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = f.WriteString(`{"t": "2026-04-01T09:30`)
+	f.Close()
+	st, err = Open(dir, 48*time.Hour, day.Add(25*time.Hour))
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	if last, ok := st.Last(); !ok || !last.Time.Equal(day.Add(24*time.Hour)) {
+		t.Fatalf("unexpected last snapshot after reopen: %+v", last)
+	}
+	if got := len(st.Range(day, day.Add(48*time.Hour))); got != 3 {
+		t.Fatalf("expected 3 snapshots after reopen, got %d", got)
+	}
+
+	// Past retention, day files are deleted and snapshots forgotten.
+	st, err = Open(dir, 48*time.Hour, day.Add(96*time.Hour))
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	if _, ok := st.Last(); ok {
+		t.Fatalf("expected expired snapshots to be dropped")
+	}
+	if names, _ := st.files(); len(names) != 0 {
+		t.Fatalf("expected expired day files to be removed, got %v", names)
+	}
+}
+
+func TestRecorder_Record(t *testing.T) {
+	srv := gbfstest.NewServer(t, `{"ttl": 60, "data": {"stations": [
+		{"station_id": "1", "num_bikes_available": 3, "num_docks_available": 2},
+		{"station_id": "2", "num_bikes_available": 1, "num_docks_available": 4}
+	]}}`)
+	t.Setenv("BIKE_GBFS_URL", srv.URL+"/gbfs.json")
+
+	groups := controller.StationGroups{
+		controller.GroupStation: {Primary: []string{"1"}},
+		controller.GroupCampus:  {Primary: []string{"2"}},
+	}
+	store, err := Open(t.TempDir(), time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	rec := &Recorder{
+		Store:   store,
+		Fetch:   controller.NewFetchController(),
+		Routes:  route.Default(groups, &bus.Timetable{}),
+		Timeout: 5 * time.Second,
+	}
+	for i := 0; i < 2; i++ {
+		if err := rec.Record(context.Background()); err != nil {
+			t.Fatalf("Record error: %v", err)
+		}
+	}
+
+	// The second poll hits the cache and is not recorded again.
+	snaps := store.Range(time.Now().Add(-time.Minute), time.Now())
+	if len(snaps) != 1 {
+		t.Fatalf("expected 1 snapshot, got %d", len(snaps))
+	}
+	s := snaps[0]
+	if s.Groups[GroupKey(route.DefaultID, controller.GroupStation)].Rentable != 3 ||
+		s.Groups[GroupKey(route.DefaultID, controller.GroupCampus)].Returnable != 4 {
+		t.Fatalf("unexpected group counts: %+v", s.Groups)
+	}
+	if s.Stations["2"].Rentable != 1 {
+		t.Fatalf("unexpected station counts: %+v", s.Stations)
+	}
+}
+
+func TestRecorder_SkipsGroupsOfFailedOperator(t *testing.T) {
+	srv := gbfstest.NewServer(t, `{"ttl": 60, "data": {"stations": [{"station_id": "1", "num_bikes_available": 3, "num_docks_available": 2}]}}`)
+	t.Setenv("BIKE_GBFS_URL", srv.URL+"/gbfs.json")
+	t.Setenv("BIKE_OPERATORS", "other="+srv.URL+"/other/gbfs.json")
+
+	groups := controller.StationGroups{
+		controller.GroupStation: {Primary: []string{"1"}},
+		controller.GroupCampus:  {Primary: []string{"1", "other:9"}},
+	}
+	store, err := Open(t.TempDir(), time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	rec := &Recorder{
+		Store:   store,
+		Fetch:   controller.NewFetchController(),
+		Routes:  route.Default(groups, &bus.Timetable{}),
+		Timeout: 5 * time.Second,
+	}
+	if err := rec.Record(context.Background()); err != nil {
+		t.Fatalf("Record error: %v", err)
+	}
+	s, ok := store.Last()
+	if !ok {
+		t.Fatalf("expected a snapshot")
+	}
+	if _, ok := s.Groups[GroupKey(route.DefaultID, controller.GroupCampus)]; ok {
+		t.Fatalf("group with a failed operator was recorded: %+v", s.Groups)
+	}
+	if s.Groups[GroupKey(route.DefaultID, controller.GroupStation)].Rentable != 3 {
+		t.Fatalf("unexpected group counts: %+v", s.Groups)
+	}
+}
@@ -0,0 +1,114 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/route"
+)
+
+// Recorder periodically snapshots bike availability for every route.
+type Recorder struct {
+	Store    *Store
+	Fetch    *controller.FetchController
+	Routes   *route.Registry
+	Interval time.Duration
+	// Timeout bounds each poll.
+	Timeout time.Duration
+}
+
+// Run polls until ctx is done, starting immediately.
+func (r *Recorder) Run(ctx context.Context) {
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+	for {
+		if err := r.Record(ctx); err != nil {
+			log.Printf("[warn] history: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Record takes one snapshot. Data already recorded (an unchanged cached
+// station_status) is skipped, as are groups using an operator that could
+// not be fetched.
+func (r *Recorder) Record(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	// All routes' groups in one fetch, keyed like Snapshot.Groups.
+	groups := controller.StationGroups{}
+	for _, rt := range r.Routes.All() {
+		for name, g := range rt.StationGroups() {
+			groups[GroupKey(rt.ID, name)] = g
+		}
+	}
+	bike, err := controller.FetchBikeTotals(ctx, r.Fetch, groups)
+	if err != nil {
+		return err
+	}
+	at := bike.FetchedAt
+	if last, ok := r.Store.Last(); ok && !at.After(last.Time) {
+		return nil
+	}
+
+	// A failed operator's stations are missing from the totals, which
+	// would record as a false drop; leave its groups out.
+	failed := map[string]bool{}
+	for _, op := range bike.Operators {
+		if op.Err != nil {
+			failed[op.ID] = true
+		}
+	}
+	usesFailed := func(g controller.StationGroup) bool {
+		for _, ids := range [][]string{g.Primary, g.Secondary} {
+			for _, ref := range ids {
+				if op, _ := controller.StationRef(ref); failed[op] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	snap := Snapshot{Time: at, Groups: map[string]Counts{}, Stations: map[string]Counts{}}
+	addStations := func(ds []controller.StationDetail) {
+		for _, d := range ds {
+			if d.Missing {
+				continue
+			}
+			snap.Stations[StationKey(d.Operator+":"+d.ID)] = Counts{Rentable: d.Rentable, Returnable: d.Returnable}
+		}
+	}
+	for key, t := range bike.Groups {
+		if usesFailed(groups[key]) {
+			continue
+		}
+		snap.Groups[key] = Counts{Rentable: t.Rentable, Returnable: t.Returnable}
+		addStations(t.Stations)
+		if t.Fallback != nil {
+			addStations(t.Fallback.Stations)
+		}
+	}
+	if len(snap.Groups) == 0 {
+		return fmt.Errorf("no complete group to record: operators %v unavailable", sortedKeys(failed))
+	}
+	return r.Store.Append(snap)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,225 @@
+// Package history records bike availability over time. A Recorder polls
+// station_status for every route's station groups and appends snapshots to
+// a Store: one JSON Lines file per day in a local directory, kept in
+// memory for queries and pruned after a retention period.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"optimal-rion/server/controller"
+)
+
+// Counts is a rentable/returnable pair.
+type Counts struct {
+	Rentable   int `json:"rentable"`
+	Returnable int `json:"returnable"`
+}
+
+// Snapshot is availability at one point in time. Groups are keyed by
+// GroupKey and stations by StationKey.
+type Snapshot struct {
+	Time     time.Time         `json:"t"`
+	Groups   map[string]Counts `json:"groups"`
+	Stations map[string]Counts `json:"stations"`
+}
+
+// GroupKey names a route's group in Snapshot.Groups.
+func GroupKey(routeID, group string) string { return routeID + "/" + group }
+
+// StationKey names a station in Snapshot.Stations: its reference, with
+// the default operator's prefix dropped.
+func StationKey(ref string) string {
+	op, id := controller.StationRef(ref)
+	if op == controller.DefaultOperator {
+		return id
+	}
+	return op + ":" + id
+}
+
+const filePrefix, fileSuffix = "bikes-", ".jsonl"
+
+// Store is an append-only snapshot log. Safe for concurrent use.
+type Store struct {
+	dir       string
+	retention time.Duration
+
+	mu    sync.RWMutex
+	snaps []Snapshot // sorted by Time
+}
+
+// Open loads the snapshots in dir younger than retention, creating dir if
+// needed, and deletes day files that have aged out.
+func Open(dir string, retention time.Duration, now time.Time) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir, retention: retention}
+	if err := s.prune(now); err != nil {
+		return nil, err
+	}
+	names, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := now.Add(-retention)
+	for _, name := range names {
+		if err := s.load(filepath.Join(dir, name), cutoff); err != nil {
+			return nil, fmt.Errorf("history: %s: %w", name, err)
+		}
+	}
+	sort.Slice(s.snaps, func(i, j int) bool { return s.snaps[i].Time.Before(s.snaps[j].Time) })
+	return s, nil
+}
+
+func (s *Store) load(path string, cutoff time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		var snap Snapshot
+		// A crash mid-append can leave a torn last line; skip it.
+		if err := json.Unmarshal(sc.Bytes(), &snap); err != nil {
+			continue
+		}
+		if !snap.Time.Before(cutoff) {
+			s.snaps = append(s.snaps, snap)
+		}
+	}
+	return sc.Err()
+}
+
+// files lists the day files in dir, oldest first.
+func (s *Store) files() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if n := e.Name(); !e.IsDir() && strings.HasPrefix(n, filePrefix) && strings.HasSuffix(n, fileSuffix) {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func dayFile(t time.Time) string {
+	return filePrefix + t.UTC().Format("20060102") + fileSuffix
+}
+
+// prune deletes day files whose whole day is past retention.
+func (s *Store) prune(now time.Time) error {
+	names, err := s.files()
+	if err != nil {
+		return err
+	}
+	keep := dayFile(now.Add(-s.retention))
+	for _, name := range names {
+		if name < keep {
+			if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Append persists a snapshot and drops expired ones from memory.
+// Snapshots must be appended in time order.
+func (s *Store) Append(snap Snapshot) error {
+	line, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prevDay := ""
+	if n := len(s.snaps); n > 0 {
+		prevDay = dayFile(s.snaps[n-1].Time)
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, dayFile(snap.Time)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	s.snaps = append(s.snaps, snap)
+	cutoff := snap.Time.Add(-s.retention)
+	i := sort.Search(len(s.snaps), func(i int) bool { return !s.snaps[i].Time.Before(cutoff) })
+	s.snaps = s.snaps[i:]
+	if prevDay != "" && prevDay != dayFile(snap.Time) {
+		return s.prune(snap.Time)
+	}
+	return nil
+}
+
+// Last returns the most recent snapshot.
+func (s *Store) Last() (Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.snaps) == 0 {
+		return Snapshot{}, false
+	}
+	return s.snaps[len(s.snaps)-1], true
+}
+
+// Range returns the snapshots in [from, to].
+func (s *Store) Range(from, to time.Time) []Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := sort.Search(len(s.snaps), func(i int) bool { return !s.snaps[i].Time.Before(from) })
+	j := sort.Search(len(s.snaps), func(i int) bool { return s.snaps[i].Time.After(to) })
+	if i >= j {
+		return nil
+	}
+	return append([]Snapshot(nil), s.snaps[i:j]...)
+}
+
+// Point is one sample of a time series.
+type Point struct {
+	Time time.Time `json:"t"`
+	Counts
+}
+
+// GroupSeries returns a group's samples in [from, to].
+func (s *Store) GroupSeries(key string, from, to time.Time) []Point {
+	var out []Point
+	for _, snap := range s.Range(from, to) {
+		if c, ok := snap.Groups[key]; ok {
+			out = append(out, Point{Time: snap.Time, Counts: c})
+		}
+	}
+	return out
+}
+
+// StationSeries returns a station's samples in [from, to].
+func (s *Store) StationSeries(ref string, from, to time.Time) []Point {
+	var out []Point
+	for _, snap := range s.Range(from, to) {
+		if c, ok := snap.Stations[StationKey(ref)]; ok {
+			out = append(out, Point{Time: snap.Time, Counts: c})
+		}
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// rainThresholdMMH is the intensity (mm/h) from which a minute counts as
+// rainy; below it is drizzle nobody notices on a short ride.
+const rainThresholdMMH = 0.1
+
+// MinutePrecip is one minute of the minutely forecast.
+type MinutePrecip struct {
+	Time      time.Time `json:"time"`
+	MMPerHour float64   `json:"mmPerHour"`
+}
+
+// PrecipSeries is a provider's minutely precipitation.
+type PrecipSeries struct {
+	Minutes  []MinutePrecip
+	Provider string
+	DataAge
+}
+
+// DryWindowDTO says when to leave to make the whole ride without rain.
+// Offsets are whole minutes from now.
+type DryWindowDTO struct {
+	// LeaveAfterMinutes is the first dry departure, LeaveWithinMinutes
+	// the last one before rain catches the ride again (-1 when dry to the
+	// end of the forecast).
+	LeaveAfterMinutes  int    `json:"leaveAfterMinutes"`
+	LeaveWithinMinutes int    `json:"leaveWithinMinutes"`
+	Suggestion         string `json:"suggestion"`
+}
+
+// RainWindowDTO is the minutely series with facts derived for a ride
+// starting now.
+type RainWindowDTO struct {
+	Minutely   []MinutePrecip `json:"minutely"`
+	RainingNow bool           `json:"rainingNow"`
+	// RainStartsAt is the first rainy minute when it is dry now;
+	// RainStopsAt the first dry minute after the current or next rain.
+	RainStartsAt *time.Time `json:"rainStartsAt,omitempty"`
+	RainStopsAt  *time.Time `json:"rainStopsAt,omitempty"`
+	RideMinutes  int        `json:"rideMinutes"`
+	// MaxIntensityMMH and RideTotalMM cover a ride leaving now.
+	MaxIntensityMMH float64 `json:"maxIntensityMmh"`
+	RideTotalMM     float64 `json:"rideTotalMm"`
+	// DryWindow is nil when no departure within the forecast stays dry.
+	DryWindow *DryWindowDTO `json:"dryWindow,omitempty"`
+	// Provider is the ID of the provider that served the data.
+	Provider string `json:"provider"`
+	DataAge  `json:"-"`
+}
+
+// AnalyzeRain derives the rain window for a ride of the given length
+// leaving at now. Minutes before now are dropped.
+func AnalyzeRain(minutely []MinutePrecip, now time.Time, ride time.Duration) RainWindowDTO {
+	rideMin := int((ride + time.Minute - 1) / time.Minute)
+	if rideMin < 1 {
+		rideMin = 1
+	}
+	out := RainWindowDTO{Minutely: []MinutePrecip{}, RideMinutes: rideMin}
+	for _, m := range minutely {
+		if m.Time.Add(time.Minute).After(now) {
+			out.Minutely = append(out.Minutely, m)
+		}
+	}
+	series := out.Minutely
+	if len(series) == 0 {
+		return out
+	}
+	wet := func(i int) bool { return series[i].MMPerHour >= rainThresholdMMH }
+
+	out.RainingNow = wet(0)
+	i := 0
+	if !out.RainingNow {
+		for i < len(series) && !wet(i) {
+			i++
+		}
+		if i < len(series) {
+			t := series[i].Time
+			out.RainStartsAt = &t
+		}
+	}
+	for i < len(series) && wet(i) {
+		i++
+	}
+	if i < len(series) && (out.RainingNow || out.RainStartsAt != nil) {
+		t := series[i].Time
+		out.RainStopsAt = &t
+	}
+
+	for j := 0; j < rideMin && j < len(series); j++ {
+		out.MaxIntensityMMH = max(out.MaxIntensityMMH, series[j].MMPerHour)
+		out.RideTotalMM += series[j].MMPerHour / 60
+	}
+
+	out.DryWindow = dryWindow(series, rideMin, wet)
+	return out
+}
+
+// dryWindow finds the first run of departures whose ride stays dry. Only
+// departures whose whole ride lies within the forecast are considered.
+func dryWindow(series []MinutePrecip, rideMin int, wet func(int) bool) *DryWindowDTO {
+	last := len(series) - rideMin // last departure fully covered
+	if last < 0 {
+		return nil
+	}
+	dry := func(d int) bool {
+		for j := d; j < d+rideMin; j++ {
+			if wet(j) {
+				return false
+			}
+		}
+		return true
+	}
+	start := 0
+	for start <= last && !dry(start) {
+		start++
+	}
+	if start > last {
+		return nil
+	}
+	end := start
+	for end+1 <= last && dry(end+1) {
+		end++
+	}
+	w := &DryWindowDTO{LeaveAfterMinutes: start, LeaveWithinMinutes: end}
+	if end == last && !hasRain(series, wet) {
+		w.LeaveWithinMinutes = -1
+	}
+	switch {
+	case w.LeaveWithinMinutes < 0:
+		w.Suggestion = "この先1時間は雨の予報はありません"
+	case start == 0 && end == 0:
+		w.Suggestion = "今すぐ出発すれば雨に降られません"
+	case start == 0:
+		w.Suggestion = fmt.Sprintf("%d分以内に出発すれば雨に降られません", end)
+	default:
+		w.Suggestion = fmt.Sprintf("%d分待ってから%d分以内に出発すれば雨に降られません", start, end-start)
+	}
+	return w
+}
+
+func hasRain(series []MinutePrecip, wet func(int) bool) bool {
+	for i := range series {
+		if wet(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchRainWindow retrieves the configured provider's minutely
+// precipitation and analyzes it for a ride leaving now.
+func FetchRainWindow(ctx context.Context, f *FetchController, lat, lon float64, ride time.Duration) (RainWindowDTO, error) {
+	p := f.Weather
+	ps, err := p.Minutely(ctx, f, lat, lon)
+	if err != nil {
+		log.Printf("FetchRainWindow: %s error: %v", p.ID(), err)
+		return RainWindowDTO{}, err
+	}
+	out := AnalyzeRain(ps.Minutes, f.now(), ride)
+	out.Provider, out.DataAge = ps.Provider, ps.DataAge
+	return out, nil
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+// minutes builds a series starting at base, one value per minute.
+func minutes(base time.Time, mmh ...float64) []MinutePrecip {
+	out := make([]MinutePrecip, len(mmh))
+	for i, v := range mmh {
+		out[i] = MinutePrecip{Time: base.Add(time.Duration(i) * time.Minute), MMPerHour: v}
+	}
+	return out
+}
+
+func TestAnalyzeRain_DryThenRain(t *testing.T) {
+	base := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	series := make([]float64, 60)
+	for i := 20; i < 35; i++ {
+		series[i] = 2.4
+	}
+	// Now is 30s into the second minute; the first is dropped.
+	rw := AnalyzeRain(minutes(base, series...), base.Add(90*time.Second), 7*time.Minute)
+
+	if len(rw.Minutely) != 59 || rw.RainingNow || rw.RideMinutes != 7 {
+		t.Fatalf("unexpected series: %d minutes, raining %v, ride %d", len(rw.Minutely), rw.RainingNow, rw.RideMinutes)
+	}
+	if rw.RainStartsAt == nil || !rw.RainStartsAt.Equal(base.Add(20*time.Minute)) {
+		t.Fatalf("unexpected rain start: %v", rw.RainStartsAt)
+	}
+	if rw.RainStopsAt == nil || !rw.RainStopsAt.Equal(base.Add(35*time.Minute)) {
+		t.Fatalf("unexpected rain stop: %v", rw.RainStopsAt)
+	}
+	if rw.MaxIntensityMMH != 0 || rw.RideTotalMM != 0 {
+		t.Fatalf("expected a dry ride now: %+v", rw)
+	}
+	// Leaving at minute 13 (offset 12) arrives just before the rain.
+	w := rw.DryWindow
+	if w == nil || w.LeaveAfterMinutes != 0 || w.LeaveWithinMinutes != 12 || w.Suggestion != "12分以内に出発すれば雨に降られません" {
+		t.Fatalf("unexpected dry window: %+v", w)
+	}
+}
+
+func TestAnalyzeRain_RainingNow(t *testing.T) {
+	base := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	series := make([]float64, 60)
+	for i := 0; i < 10; i++ {
+		series[i] = 6
+	}
+	rw := AnalyzeRain(minutes(base, series...), base, 5*time.Minute)
+
+	if !rw.RainingNow || rw.RainStartsAt != nil {
+		t.Fatalf("expected rain now: %+v", rw)
+	}
+	if rw.RainStopsAt == nil || !rw.RainStopsAt.Equal(base.Add(10*time.Minute)) {
+		t.Fatalf("unexpected rain stop: %v", rw.RainStopsAt)
+	}
+	if rw.MaxIntensityMMH != 6 || rw.RideTotalMM != 0.5 {
+		t.Fatalf("unexpected ride rain: max %v total %v", rw.MaxIntensityMMH, rw.RideTotalMM)
+	}
+	w := rw.DryWindow
+	if w == nil || w.LeaveAfterMinutes != 10 || w.LeaveWithinMinutes != 55 {
+		t.Fatalf("unexpected dry window: %+v", w)
+	}
+}
+
+func TestAnalyzeRain_NoWindow(t *testing.T) {
+	base := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	series := make([]float64, 60)
+	for i := range series {
+		if i%10 == 0 {
+			series[i] = 1
+		}
+	}
+	if rw := AnalyzeRain(minutes(base, series...), base, 15*time.Minute); rw.DryWindow != nil {
+		t.Fatalf("expected no dry window, got %+v", rw.DryWindow)
+	}
+	if rw := AnalyzeRain(minutes(base, make([]float64, 60)...), base, 15*time.Minute); rw.DryWindow == nil || rw.DryWindow.LeaveWithinMinutes != -1 {
+		t.Fatalf("expected dry to the end of the forecast, got %+v", rw.DryWindow)
+	}
+}
@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Recommended commute modes.
+const (
+	ModeBike = "bike"
+	ModeBus  = "bus"
+)
+
+// RecommendInput is everything the decision engine looks at for one trip.
+// Bike counts are for the trip direction: rentable bikes at the departure
+// group and returnable docks at the destination group.
+type RecommendInput struct {
+	Weather                WeatherDTO
+	AvailableAtDeparture   int
+	AvailableAtDestination int
+	// Fallback counts are for the groups' secondary stations, a longer
+	// walk away; they keep the bike possible when the primaries are empty.
+	FallbackAtDeparture   int
+	FallbackAtDestination int
+	// NextBusIn is the wait until the next bus leaves; nil when the
+	// timetable is unknown.
+	NextBusIn *time.Duration
+	// NoMoreBuses reports that the last bus of the day has already left.
+	NoMoreBuses bool
+	// Difficulty rates the ride against the wind and climb; nil when
+	// unknown.
+	Difficulty *DifficultyDTO
+	// WeatherUnavailable and BikesUnavailable mark inputs that could not
+	// be fetched; their zero values must not be read as real conditions.
+	WeatherUnavailable bool
+	BikesUnavailable   bool
+}
+
+// Recommendation is the engine output shared by every client.
+type Recommendation struct {
+	Mode       string   `json:"mode"`
+	Confidence float64  `json:"confidence"`
+	Reasons    []string `json:"reasons"`
+}
+
+// Thresholds used by Recommend. Positive scores favour the bike.
+const (
+	rainHeavyMMPerHour = 1.0
+	uvHigh             = 6.0
+	uvVeryHigh         = 8.0
+	tempHot            = 30.0
+	tempVeryHot        = 33.0
+	tempCold           = 3.0
+	windStrongMS       = 7.0
+	windVeryStrongMS   = 10.0
+	fewBikes           = 2
+	busSoon            = 5 * time.Minute
+	busLongWait        = 20 * time.Minute
+	bikeBias           = 1.0
+)
+
+// Recommend combines weather, bike availability and the next bus into a
+// bike-or-bus decision with a confidence in [0.5, 1] and readable reasons.
+func Recommend(in RecommendInput) Recommendation {
+	var score float64
+	var reasons []string
+	add := func(delta float64, reason string) {
+		score += delta
+		reasons = append(reasons, reason)
+	}
+
+	// Hard constraints: no bike to rent or nowhere to return it.
+	bikeImpossible := false
+	if !in.BikesUnavailable && in.AvailableAtDeparture+in.FallbackAtDeparture <= 0 {
+		bikeImpossible = true
+		reasons = append(reasons, "出発地に貸出可能な自転車がありません")
+	}
+	if !in.BikesUnavailable && in.AvailableAtDestination+in.FallbackAtDestination <= 0 {
+		bikeImpossible = true
+		reasons = append(reasons, "目的地に返却可能な空きがありません")
+	}
+	noBus := in.NoMoreBuses
+
+	switch {
+	case bikeImpossible && noBus:
+		reasons = append(reasons, "本日のバスは終了しています")
+		return Recommendation{Mode: ModeBus, Confidence: 0.5, Reasons: reasons}
+	case bikeImpossible:
+		return Recommendation{Mode: ModeBus, Confidence: 1, Reasons: reasons}
+	case noBus && in.BikesUnavailable:
+		// The bike is the only option left, but nobody knows if one is free.
+		reasons = append(reasons, "本日のバスは終了しています", "自転車の空き状況を取得できませんでした")
+		return Recommendation{Mode: ModeBike, Confidence: 0.5, Reasons: reasons}
+	case noBus:
+		return Recommendation{Mode: ModeBike, Confidence: 1, Reasons: append(reasons, "本日のバスは終了しています")}
+	}
+
+	if in.WeatherUnavailable {
+		reasons = append(reasons, "天気情報を取得できませんでした")
+	} else {
+		weatherFactors(in.Weather, in.Difficulty, add)
+	}
+
+	if in.BikesUnavailable {
+		reasons = append(reasons, "自転車の空き状況を取得できませんでした")
+	} else {
+		switch {
+		case in.AvailableAtDeparture <= 0:
+			add(-0.75, fmt.Sprintf("最寄りのポートに自転車がなく、離れたポートを使う必要があります (%d台)", in.FallbackAtDeparture))
+		case in.AvailableAtDeparture <= fewBikes:
+			add(-0.5, fmt.Sprintf("出発地の自転車が残りわずかです (%d台)", in.AvailableAtDeparture))
+		}
+		switch {
+		case in.AvailableAtDestination <= 0:
+			add(-0.75, fmt.Sprintf("最寄りのポートに空きがなく、離れたポートに返却する必要があります (%d台)", in.FallbackAtDestination))
+		case in.AvailableAtDestination <= fewBikes:
+			add(-0.5, fmt.Sprintf("目的地の空きが残りわずかです (%d台)", in.AvailableAtDestination))
+		}
+	}
+
+	if in.NextBusIn != nil {
+		wait := *in.NextBusIn
+		mins := int(wait.Round(time.Minute) / time.Minute)
+		switch {
+		case wait >= busLongWait:
+			add(1.5, fmt.Sprintf("次のバスまで%d分待ちます", mins))
+		case wait <= busSoon:
+			add(-0.5, fmt.Sprintf("次のバスが%d分後に出発します", mins))
+		}
+	}
+
+	// Riding is the default when nothing argues against it.
+	score += bikeBias
+	if len(reasons) == 0 {
+		reasons = append(reasons, "天候・自転車の状況ともに良好です")
+	}
+
+	mode := ModeBike
+	if score < 0 {
+		mode = ModeBus
+	}
+	conf := confidence(score)
+	// Each missing input halves how far we stray from a coin toss.
+	for _, missing := range []bool{in.WeatherUnavailable, in.BikesUnavailable} {
+		if missing {
+			conf = 0.5 + (conf-0.5)/2
+		}
+	}
+	return Recommendation{Mode: mode, Confidence: math.Round(conf*100) / 100, Reasons: reasons}
+}
+
+// weatherFactors scores rain, UV, temperature and wind. The heat stroke
+// risk, when estimated, replaces the hot-temperature checks since it
+// already accounts for humidity, sun and wind; likewise the ride's
+// difficulty replaces the strong-wind check, as a tailwind is no burden.
+func weatherFactors(w WeatherDTO, diff *DifficultyDTO, add func(delta float64, reason string)) {
+	switch {
+	case w.Precip10Min >= rainHeavyMMPerHour:
+		add(-3, fmt.Sprintf("10分後に強い雨の予報です (%.1f mm/h)", w.Precip10Min))
+	case w.Precip10Min > 0:
+		add(-1.5, fmt.Sprintf("10分後に雨の予報です (%.1f mm/h)", w.Precip10Min))
+	}
+	switch {
+	case w.UVIndex >= uvVeryHigh:
+		add(-1, fmt.Sprintf("UV指数が非常に高いです (%.1f)", w.UVIndex))
+	case w.UVIndex >= uvHigh:
+		add(-0.5, fmt.Sprintf("UV指数が高いです (%.1f)", w.UVIndex))
+	}
+	if w.Heat != nil {
+		switch w.Heat.Level {
+		case HeatDanger:
+			add(-3, fmt.Sprintf("熱中症の危険性が極めて高い暑さです (WBGT推定 %.1f°C)", w.Heat.WBGTC))
+		case HeatSevere:
+			add(-1.5, fmt.Sprintf("熱中症に厳重警戒が必要な暑さです (WBGT推定 %.1f°C)", w.Heat.WBGTC))
+		case HeatWarning:
+			add(-0.5, fmt.Sprintf("熱中症に警戒が必要な暑さです (WBGT推定 %.1f°C)", w.Heat.WBGTC))
+		}
+	} else {
+		switch {
+		case w.TemperatureC >= tempVeryHot:
+			add(-1.5, fmt.Sprintf("猛暑です (%.1f°C)", w.TemperatureC))
+		case w.TemperatureC >= tempHot:
+			add(-0.75, fmt.Sprintf("暑いです (%.1f°C)", w.TemperatureC))
+		}
+	}
+	if w.TemperatureC <= tempCold {
+		add(-0.75, fmt.Sprintf("寒いです (%.1f°C)", w.TemperatureC))
+	}
+
+	if w.WindSpeedMS >= windVeryStrongMS {
+		add(-1.5, fmt.Sprintf("非常に強い風です (%.1f m/s)", w.WindSpeedMS))
+		return
+	}
+	if diff == nil {
+		if w.WindSpeedMS >= windStrongMS {
+			add(-0.75, fmt.Sprintf("風が強いです (%.1f m/s)", w.WindSpeedMS))
+		}
+		return
+	}
+	switch {
+	case diff.HeadwindUphill:
+		add(-1, fmt.Sprintf("上り坂で向かい風を受けます (%.1f m/s)", diff.HeadwindMS))
+	case diff.Level == DifficultyHard || diff.Level == DifficultyVeryHard:
+		add(-0.75, fmt.Sprintf("向かい風で走りにくい状況です (難易度 %.1f)", diff.Score))
+	}
+}
+
+// confidence maps the magnitude of a score to [0.5, 1] with a logistic curve.
+func confidence(score float64) float64 {
+	c := 1 / (1 + math.Exp(-math.Abs(score)))
+	return math.Round(c*100) / 100
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func durPtr(d time.Duration) *time.Duration { return &d }
+
+func TestRecommend_GoodConditionsPickBike(t *testing.T) {
+	rec := Recommend(RecommendInput{
+		Weather:                WeatherDTO{UVIndex: 2, TemperatureC: 20, HumidityPercent: 50},
+		AvailableAtDeparture:   8,
+		AvailableAtDestination: 10,
+		NextBusIn:              durPtr(10 * time.Minute),
+	})
+	if rec.Mode != ModeBike {
+		t.Fatalf("unexpected mode: got %s want %s", rec.Mode, ModeBike)
+	}
+	if rec.Confidence < 0.5 || rec.Confidence > 1 {
+		t.Fatalf("confidence out of range: %.2f", rec.Confidence)
+	}
+	if len(rec.Reasons) == 0 {
+		t.Fatalf("expected at least one reason")
+	}
+}
+
+func TestRecommend_RainPicksBus(t *testing.T) {
+	rec := Recommend(RecommendInput{
+		Weather:                WeatherDTO{TemperatureC: 20, Precip10Min: 2.5},
+		AvailableAtDeparture:   8,
+		AvailableAtDestination: 10,
+		NextBusIn:              durPtr(10 * time.Minute),
+	})
+	if rec.Mode != ModeBus {
+		t.Fatalf("unexpected mode: got %s want %s", rec.Mode, ModeBus)
+	}
+}
+
+func TestRecommend_NoBikesForcesBus(t *testing.T) {
+	rec := Recommend(RecommendInput{
+		Weather:                WeatherDTO{TemperatureC: 20},
+		AvailableAtDeparture:   0,
+		AvailableAtDestination: 10,
+		NextBusIn:              durPtr(30 * time.Minute),
+	})
+	if rec.Mode != ModeBus || rec.Confidence != 1 {
+		t.Fatalf("unexpected recommendation: %+v", rec)
+	}
+}
+
+func TestRecommend_NoMoreBusesForcesBike(t *testing.T) {
+	rec := Recommend(RecommendInput{
+		Weather:                WeatherDTO{TemperatureC: 20, Precip10Min: 2.5},
+		AvailableAtDeparture:   3,
+		AvailableAtDestination: 3,
+		NoMoreBuses:            true,
+	})
+	if rec.Mode != ModeBike || rec.Confidence != 1 {
+		t.Fatalf("unexpected recommendation: %+v", rec)
+	}
+}
+
+func TestRecommend_NoMoreBusesWithUnavailableBikes(t *testing.T) {
+	rec := Recommend(RecommendInput{
+		Weather:          WeatherDTO{TemperatureC: 20},
+		BikesUnavailable: true,
+		NoMoreBuses:      true,
+	})
+	if rec.Mode != ModeBike || rec.Confidence != 0.5 {
+		t.Fatalf("unexpected recommendation: %+v", rec)
+	}
+	if !slices.Contains(rec.Reasons, "自転車の空き状況を取得できませんでした") {
+		t.Fatalf("missing unavailable-bikes reason: %v", rec.Reasons)
+	}
+}
+
+func TestRecommend_UnavailableBikesAreNotZero(t *testing.T) {
+	rec := Recommend(RecommendInput{
+		Weather:          WeatherDTO{TemperatureC: 20},
+		BikesUnavailable: true,
+		NextBusIn:        durPtr(10 * time.Minute),
+	})
+	if rec.Mode != ModeBike {
+		t.Fatalf("unavailable bike data must not force the bus: %+v", rec)
+	}
+	if rec.Confidence >= 0.7 {
+		t.Fatalf("confidence should drop with missing data: %.2f", rec.Confidence)
+	}
+}
+
+func TestRecommend_HeatRiskReplacesTemperature(t *testing.T) {
+	in := RecommendInput{
+		Weather:                WeatherDTO{TemperatureC: 34, Heat: &HeatRiskDTO{WBGTC: 32, Level: HeatDanger}},
+		AvailableAtDeparture:   8,
+		AvailableAtDestination: 10,
+		NextBusIn:              durPtr(10 * time.Minute),
+	}
+	if rec := Recommend(in); rec.Mode != ModeBus || len(rec.Reasons) != 1 {
+		t.Fatalf("expected a single heat reason picking the bus: %+v", rec)
+	}
+	// Hot but dry and breezy: the WBGT level, not the temperature, decides.
+	in.Weather.Heat = &HeatRiskDTO{WBGTC: 23, Level: HeatCaution}
+	if rec := Recommend(in); rec.Mode != ModeBike {
+		t.Fatalf("expected bike at caution level: %+v", rec)
+	}
+}
+
+// Cold is scored once whether or not a heat estimate exists, and a heat
+// estimate never adds a temperature reason on top of its own.
+func TestRecommend_ColdAndHeatDoNotDoubleCount(t *testing.T) {
+	in := RecommendInput{
+		Weather:                WeatherDTO{TemperatureC: 1},
+		AvailableAtDeparture:   8,
+		AvailableAtDestination: 10,
+	}
+	plain := Recommend(in)
+	in.Weather.Heat = &HeatRiskDTO{WBGTC: 0.4, Level: HeatSafe}
+	withHeat := Recommend(in)
+	if len(withHeat.Reasons) != 1 || withHeat.Reasons[0] != "寒いです (1.0°C)" || withHeat.Confidence != plain.Confidence {
+		t.Fatalf("expected the cold reason alone: %+v vs %+v", withHeat, plain)
+	}
+
+	in.Weather = WeatherDTO{TemperatureC: 34, Heat: &HeatRiskDTO{WBGTC: 29, Level: HeatSevere}}
+	if rec := Recommend(in); len(rec.Reasons) != 1 || !strings.Contains(rec.Reasons[0], "WBGT") {
+		t.Fatalf("expected the heat reason alone: %+v", rec)
+	}
+}
+
+func TestRecommend_UphillHeadwindCounts(t *testing.T) {
+	in := RecommendInput{
+		Weather:                WeatherDTO{TemperatureC: 20, WindSpeedMS: 8, WindDeg: 0},
+		AvailableAtDeparture:   8,
+		AvailableAtDestination: 10,
+		NextBusIn:              durPtr(10 * time.Minute),
+	}
+	in.Difficulty = CyclingDifficulty(in.Weather, 0, 1.5, 20)
+	uphill := Recommend(in)
+	if len(uphill.Reasons) != 1 {
+		t.Fatalf("expected a single headwind reason: %+v", uphill)
+	}
+	// Downwind, the same wind is no reason against the bike.
+	in.Difficulty = CyclingDifficulty(in.Weather, 180, 1.5, -20)
+	if rec := Recommend(in); rec.Mode != ModeBike || rec.Confidence <= uphill.Confidence || rec.Reasons[0] != "天候・自転車の状況ともに良好です" {
+		t.Fatalf("unexpected downwind recommendation: %+v", rec)
+	}
+}
@@ -0,0 +1,243 @@
+// Package route is the registry of commutes the server knows about. Each
+// route joins a home-side origin (e.g. a train station) to a campus, with
+// the bike stations and shuttle timetable serving both ends.
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/bus"
+)
+
+// Direction of a trip along a route.
+type Direction string
+
+const (
+	ToSchool Direction = "to-school"
+	ToHome   Direction = "to-home"
+)
+
+// Endpoint is one end of a route.
+type Endpoint struct {
+	Name     string                  `json:"name"`
+	Lat      float64                 `json:"lat"`
+	Lon      float64                 `json:"lon"`
+	Stations controller.StationGroup `json:"stations"`
+}
+
+// Route is a single commute.
+type Route struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Origin      Endpoint `json:"origin"`
+	Destination Endpoint `json:"destination"`
+	// BusTimetable is a timetable file path, relative to the routes file;
+	// empty uses the embedded Niiza shuttle timetable.
+	BusTimetable string `json:"busTimetable,omitempty"`
+	// RideMinutes is the typical bike ride; zero estimates it from the
+	// endpoints' coordinates.
+	RideMinutes float64 `json:"rideMinutes,omitempty"`
+	// ClimbMeters is the height gained riding from origin to destination;
+	// negative when the destination is lower. Zero treats the route as flat.
+	ClimbMeters float64 `json:"climbMeters,omitempty"`
+
+	Timetable *bus.Timetable `json:"-"`
+}
+
+// Leg is a route seen in one direction.
+type Leg struct {
+	Departure, Arrival           Endpoint
+	DepartureGroup, ArrivalGroup string
+	Bus                          bus.Direction
+	// Climb is the height gained on this leg, in metres.
+	Climb float64
+}
+
+// Leg returns the trip for a direction; ok is false for unknown directions.
+func (r *Route) Leg(dir Direction) (Leg, bool) {
+	switch dir {
+	case ToSchool:
+		return Leg{r.Origin, r.Destination, controller.GroupStation, controller.GroupCampus, bus.ToCampus, r.ClimbMeters}, true
+	case ToHome:
+		return Leg{r.Destination, r.Origin, controller.GroupCampus, controller.GroupStation, bus.FromCampus, -r.ClimbMeters}, true
+	}
+	return Leg{}, false
+}
+
+// Bearing is the initial compass bearing, in degrees, from departure to
+// arrival.
+func (l Leg) Bearing() float64 {
+	rad := math.Pi / 180
+	lat1, lat2 := l.Departure.Lat*rad, l.Arrival.Lat*rad
+	dLon := (l.Arrival.Lon - l.Departure.Lon) * rad
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)/rad+360, 360)
+}
+
+// DistanceKM is the riding distance, estimated like RideTime.
+func (l Leg) DistanceKM() float64 {
+	return haversineKM(l.Departure.Lat, l.Departure.Lon, l.Arrival.Lat, l.Arrival.Lon) * detourFactor
+}
+
+// StationGroups returns the route's bike groups keyed by the group names
+// used in Leg.
+func (r *Route) StationGroups() controller.StationGroups {
+	return controller.StationGroups{
+		controller.GroupStation: r.Origin.Stations,
+		controller.GroupCampus:  r.Destination.Stations,
+	}
+}
+
+// Ride-time estimate: straight-line distance stretched to account for
+// streets, at a relaxed e-bike pace.
+const (
+	detourFactor = 1.3
+	rideSpeedKMH = 15.0
+)
+
+// RideTime is how long the bike ride between the endpoints takes.
+func (r *Route) RideTime() time.Duration {
+	if r.RideMinutes > 0 {
+		return time.Duration(r.RideMinutes * float64(time.Minute))
+	}
+	km := haversineKM(r.Origin.Lat, r.Origin.Lon, r.Destination.Lat, r.Destination.Lon) * detourFactor
+	return time.Duration(km / rideSpeedKMH * float64(time.Hour)).Round(time.Minute)
+}
+
+func haversineKM(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthKM = 6371.0
+	rad := math.Pi / 180
+	dLat, dLon := (lat2-lat1)*rad, (lon2-lon1)*rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthKM * math.Asin(math.Sqrt(a))
+}
+
+// WeatherPoint is where weather is fetched for the route: the campus.
+func (r *Route) WeatherPoint() (lat, lon float64) {
+	return r.Destination.Lat, r.Destination.Lon
+}
+
+// Registry holds every configured route.
+type Registry struct {
+	routes    map[string]*Route
+	defaultID string
+}
+
+type registryFile struct {
+	Default string   `json:"default"`
+	Routes  []*Route `json:"routes"`
+}
+
+// DefaultID is the id of the built-in Niiza route.
+const DefaultID = "niiza"
+
+// defaultClimbMeters is the height gained riding from Niiza station, about
+// 28 m above sea level, up to the Niiza campus at about 44 m.
+const defaultClimbMeters = 16
+
+// Default builds a registry with only the built-in Niiza route, using the
+// given station groups and timetable.
+func Default(groups controller.StationGroups, tt *bus.Timetable) *Registry {
+	r := &Route{
+		ID:    DefaultID,
+		Title: "Rionized",
+		Origin: Endpoint{
+			Name: "新座駅", Lat: 35.803395, Lon: 139.565086,
+			Stations: groups[controller.GroupStation],
+		},
+		Destination: Endpoint{
+			Name: "新座キャンパス", Lat: 35.813583, Lon: 139.565710,
+			Stations: groups[controller.GroupCampus],
+		},
+		ClimbMeters: defaultClimbMeters,
+		Timetable:   tt,
+	}
+	return &Registry{routes: map[string]*Route{r.ID: r}, defaultID: r.ID}
+}
+
+// Load reads a routes file and each route's bus timetable. days resolves
+// timetable variants for every route.
+func Load(path string, days bus.DayResolver) (*Registry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f, filepath.Dir(path), days)
+}
+
+func parse(rd io.Reader, baseDir string, days bus.DayResolver) (*Registry, error) {
+	var rf registryFile
+	if err := json.NewDecoder(rd).Decode(&rf); err != nil {
+		return nil, err
+	}
+	reg := &Registry{routes: map[string]*Route{}, defaultID: rf.Default}
+	for _, r := range rf.Routes {
+		if r.ID == "" {
+			return nil, fmt.Errorf("routes: route without id")
+		}
+		if _, dup := reg.routes[r.ID]; dup {
+			return nil, fmt.Errorf("routes: duplicate id %q", r.ID)
+		}
+		if len(r.Origin.Stations.Primary) == 0 || len(r.Destination.Stations.Primary) == 0 {
+			return nil, fmt.Errorf("routes: %q needs primary stations at both ends", r.ID)
+		}
+		ttPath := r.BusTimetable
+		if ttPath != "" && !filepath.IsAbs(ttPath) {
+			ttPath = filepath.Join(baseDir, ttPath)
+		}
+		tt, err := bus.Load(ttPath)
+		if err != nil {
+			return nil, fmt.Errorf("routes: %q timetable: %w", r.ID, err)
+		}
+		if days != nil {
+			tt.UseCalendar(days)
+		}
+		r.Timetable = tt
+		if r.Title == "" {
+			r.Title = "Rionized"
+		}
+		reg.routes[r.ID] = r
+	}
+	if len(reg.routes) == 0 {
+		return nil, fmt.Errorf("routes: no routes configured")
+	}
+	if reg.defaultID == "" {
+		reg.defaultID = rf.Routes[0].ID
+	}
+	if _, ok := reg.routes[reg.defaultID]; !ok {
+		return nil, fmt.Errorf("routes: default %q is not a configured route", reg.defaultID)
+	}
+	return reg, nil
+}
+
+// Get looks up a route by id.
+func (reg *Registry) Get(id string) (*Route, bool) {
+	r, ok := reg.routes[id]
+	return r, ok
+}
+
+// Default returns the route served by the legacy /api/app, /api/cycle,
+// /api/bus and /api/recommend endpoints.
+func (reg *Registry) Default() *Route {
+	return reg.routes[reg.defaultID]
+}
+
+// All returns every route sorted by id.
+func (reg *Registry) All() []*Route {
+	out := make([]*Route, 0, len(reg.routes))
+	for _, r := range reg.routes {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
@@ -0,0 +1,138 @@
+package route
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/bus"
+)
+
+const testRoutes = `{
+  "routes": [
+    {
+      "id": "a",
+      "origin": {"name": "A駅", "lat": 1, "lon": 2, "stations": {"primary": ["1"]}},
+      "destination": {"name": "Aキャンパス", "lat": 3, "lon": 4, "stations": {"primary": ["2"], "secondary": ["3"]}}
+    },
+    {
+      "id": "b",
+      "title": "B commute",
+      "origin": {"name": "B駅", "stations": {"primary": ["4"]}},
+      "destination": {"name": "Bキャンパス", "stations": {"primary": ["5"]}}
+    }
+  ]
+}`
+
+func TestParse_RoutesAndLegs(t *testing.T) {
+	reg, err := parse(strings.NewReader(testRoutes), ".", nil)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if reg.Default().ID != "a" || reg.Default().Title != "Rionized" {
+		t.Fatalf("unexpected default route: %+v", reg.Default())
+	}
+	b, ok := reg.Get("b")
+	if !ok || b.Title != "B commute" || b.Timetable == nil {
+		t.Fatalf("unexpected route b: %+v", b)
+	}
+
+	a, _ := reg.Get("a")
+	leg, ok := a.Leg(ToHome)
+	if !ok || leg.Departure.Name != "Aキャンパス" || leg.ArrivalGroup != controller.GroupStation || leg.Bus != bus.FromCampus {
+		t.Fatalf("unexpected to-home leg: %+v", leg)
+	}
+	if _, ok := a.Leg("sideways"); ok {
+		t.Fatalf("unknown direction should not resolve")
+	}
+	if got := a.StationGroups()[controller.GroupCampus].Secondary; len(got) != 1 || got[0] != "3" {
+		t.Fatalf("unexpected campus group: %+v", got)
+	}
+	if lat, lon := a.WeatherPoint(); lat != 3 || lon != 4 {
+		t.Fatalf("unexpected weather point: %v,%v", lat, lon)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]string{
+		"no routes":       `{"routes": []}`,
+		"duplicate id":    `{"routes": [{"id": "a", "origin": {"stations": {"primary": ["1"]}}, "destination": {"stations": {"primary": ["2"]}}}, {"id": "a", "origin": {"stations": {"primary": ["1"]}}, "destination": {"stations": {"primary": ["2"]}}}]}`,
+		"no stations":     `{"routes": [{"id": "a"}]}`,
+		"unknown default": `{"default": "x", "routes": [{"id": "a", "origin": {"stations": {"primary": ["1"]}}, "destination": {"stations": {"primary": ["2"]}}}]}`,
+	}
+	for name, body := range cases {
+		if _, err := parse(strings.NewReader(body), ".", nil); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_TimetableRelativeToRoutesFile(t *testing.T) {
+	dir := t.TempDir()
+	tt := `{"toCampus": {"from": "X", "to": "Y", "departures": {"weekday": ["08:00"]}}}`
+	if err := os.WriteFile(filepath.Join(dir, "tt.json"), []byte(tt), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	routes := `{"routes": [{"id": "a", "busTimetable": "tt.json",
+		"origin": {"stations": {"primary": ["1"]}}, "destination": {"stations": {"primary": ["2"]}}}]}`
+	path := filepath.Join(dir, "routes.json")
+	if err := os.WriteFile(path, []byte(routes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reg, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if from, _ := reg.Default().Timetable.Stops(bus.ToCampus); from != "X" {
+		t.Fatalf("route timetable not loaded, from=%q", from)
+	}
+}
+
+func TestRoute_RideTime(t *testing.T) {
+	r := Default(controller.DefaultStationGroups, nil).Default()
+	// Niiza station to campus is about 1.1 km as the crow flies.
+	if got := r.RideTime(); got < 5*time.Minute || got > 7*time.Minute {
+		t.Fatalf("unexpected estimated ride time: %v", got)
+	}
+	r.RideMinutes = 12
+	if got := r.RideTime(); got != 12*time.Minute {
+		t.Fatalf("configured ride time ignored: %v", got)
+	}
+}
+
+func TestLeg_BearingAndClimb(t *testing.T) {
+	r := Default(controller.DefaultStationGroups, nil).Default()
+	r.ClimbMeters = 20
+	toSchool, _ := r.Leg(ToSchool)
+	toHome, _ := r.Leg(ToHome)
+	// The campus is almost due north of the station.
+	if b := toSchool.Bearing(); b > 5 && b < 355 {
+		t.Fatalf("unexpected to-school bearing: %.1f", b)
+	}
+	if b := toHome.Bearing(); b < 175 || b > 185 {
+		t.Fatalf("unexpected to-home bearing: %.1f", b)
+	}
+	if toSchool.Climb != 20 || toHome.Climb != -20 {
+		t.Fatalf("unexpected climbs: %.0f / %.0f", toSchool.Climb, toHome.Climb)
+	}
+}
+
+// The default route climbs to the campus, so a strong north wind is a
+// headwind uphill on the way to school but not on the way home.
+func TestDefault_HeadwindUphillToSchool(t *testing.T) {
+	r := Default(controller.DefaultStationGroups, nil).Default()
+	w := controller.WeatherDTO{WindSpeedMS: 8, WindGustMS: 12, WindDeg: 0}
+
+	toSchool, _ := r.Leg(ToSchool)
+	d := controller.CyclingDifficulty(w, toSchool.Bearing(), toSchool.DistanceKM(), toSchool.Climb)
+	if !d.HeadwindUphill || d.GradePercent < 1 {
+		t.Fatalf("expected to-school leg flagged: %+v", d)
+	}
+	toHome, _ := r.Leg(ToHome)
+	if d := controller.CyclingDifficulty(w, toHome.Bearing(), toHome.DistanceKM(), toHome.Climb); d.HeadwindUphill {
+		t.Fatalf("unexpected flag on the way home: %+v", d)
+	}
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Station group names the handlers rely on.
+const (
+	GroupStation = "station"
+	GroupCampus  = "campus"
+)
+
+// StationGroup is a named set of bike-share ports. Secondary stations are
+// a little farther away and only matter when the primary ones run out.
+// Stations are referenced as "operator:id"; a bare id belongs to
+// DefaultOperator (Hello Cycling).
+type StationGroup struct {
+	Primary   []string `json:"primary"`
+	Secondary []string `json:"secondary,omitempty"`
+}
+
+// StationGroups maps group names to their stations.
+type StationGroups map[string]StationGroup
+
+// DefaultStationGroups are the Hello Cycling ports around Niiza station and
+// the Niiza campus (public IDs; safe to embed).
+var DefaultStationGroups = StationGroups{
+	GroupStation: {Primary: []string{"6504", "6503", "7060", "6502", "23069"}},
+	GroupCampus:  {Primary: []string{"14743", "5770", "5769", "3151", "4223", "3150", "16774", "5778", "5776", "6832"}},
+}
+
+type stationGroupsFile struct {
+	Groups StationGroups `json:"groups"`
+}
+
+// LoadStationGroups reads station groups from a JSON file (DefaultStationGroups
+// when path is empty), then applies <NAME>_BIKE_PRIMARY and
+// <NAME>_BIKE_SECONDARY environment overrides, e.g. CAMPUS_BIKE_PRIMARY,
+// holding comma-separated station IDs.
+func LoadStationGroups(path string) (StationGroups, error) {
+	groups := StationGroups{}
+	if path == "" {
+		for name, g := range DefaultStationGroups {
+			groups[name] = g
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if groups, err = ParseStationGroups(f); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, g := range groups {
+		prefix := strings.ToUpper(name) + "_BIKE_"
+		if v, ok := os.LookupEnv(prefix + "PRIMARY"); ok {
+			g.Primary = splitIDs(v)
+		}
+		if v, ok := os.LookupEnv(prefix + "SECONDARY"); ok {
+			g.Secondary = splitIDs(v)
+		}
+		groups[name] = g
+	}
+	return groups, groups.validate()
+}
+
+// ParseStationGroups decodes station groups from JSON.
+func ParseStationGroups(r io.Reader) (StationGroups, error) {
+	var sf stationGroupsFile
+	if err := json.NewDecoder(r).Decode(&sf); err != nil {
+		return nil, err
+	}
+	if sf.Groups == nil {
+		return StationGroups{}, nil
+	}
+	return sf.Groups, nil
+}
+
+func (gs StationGroups) validate() error {
+	for _, name := range []string{GroupStation, GroupCampus} {
+		if len(gs[name].Primary) == 0 {
+			return fmt.Errorf("station groups: %q needs at least one primary station", name)
+		}
+	}
+	return nil
+}
+
+func splitIDs(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadStationGroups_FileAndEnvOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stations.json")
+	body := `{"groups": {
+		"station": {"primary": ["1", "2"], "secondary": ["3"]},
+		"campus": {"primary": ["10"]}
+	}}`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CAMPUS_BIKE_PRIMARY", " 11, 12 ,")
+	t.Setenv("CAMPUS_BIKE_SECONDARY", "13")
+
+	groups, err := LoadStationGroups(path)
+	if err != nil {
+		t.Fatalf("LoadStationGroups error: %v", err)
+	}
+	if got := groups[GroupStation]; !reflect.DeepEqual(got, StationGroup{Primary: []string{"1", "2"}, Secondary: []string{"3"}}) {
+		t.Fatalf("unexpected station group: %+v", got)
+	}
+	if got := groups[GroupCampus]; !reflect.DeepEqual(got, StationGroup{Primary: []string{"11", "12"}, Secondary: []string{"13"}}) {
+		t.Fatalf("unexpected campus group: %+v", got)
+	}
+}
+
+func TestLoadStationGroups_RequiresPrimaryStations(t *testing.T) {
+	t.Setenv("STATION_BIKE_PRIMARY", "")
+	if _, err := LoadStationGroups(""); err == nil {
+		t.Fatalf("expected error for empty station group")
+	}
+}
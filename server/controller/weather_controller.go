@@ -3,95 +3,163 @@ package controller
 import (
 	"context"
 	"log"
-	"os"
-	"strconv"
+	"time"
 )
 
-const oneCall3 = "https://api.openweathermap.org/data/3.0/onecall"
+// OpenWeather updates minutely data about once a minute; a short TTL keeps
+// the 10-minute precipitation meaningful while saving quota.
+var oneCallPolicy = CachePolicy{TTL: 2 * time.Minute, MaxStale: 10 * time.Minute}
 
-// OneCallResponse is a partial model of the One Call API 3.0 response
-// containing only fields we need: current weather and minutely forecast.
+// OneCallResponse models the One Call API 3.0 response: current
+// conditions, minutely precipitation, hourly and daily forecasts and
+// government alerts.
 type OneCallResponse struct {
 	Lat            float64 `json:"lat"`
 	Lon            float64 `json:"lon"`
 	Timezone       string  `json:"timezone"`
 	TimezoneOffset int     `json:"timezone_offset"`
 	Current        struct {
-		Dt        int64   `json:"dt"`
-		Temp      float64 `json:"temp"`
-		FeelsLike float64 `json:"feels_like"`
-		Pressure  int     `json:"pressure"`
-		Humidity  int     `json:"humidity"`
-		UVI       float64 `json:"uvi"`
-		Clouds    int     `json:"clouds"`
-		WindSpeed float64 `json:"wind_speed"`
-		WindDeg   int     `json:"wind_deg"`
+		Dt         int64            `json:"dt"`
+		Sunrise    int64            `json:"sunrise"`
+		Sunset     int64            `json:"sunset"`
+		Temp       float64          `json:"temp"`
+		FeelsLike  float64          `json:"feels_like"`
+		Pressure   int              `json:"pressure"`
+		Humidity   int              `json:"humidity"`
+		DewPoint   float64          `json:"dew_point"`
+		UVI        float64          `json:"uvi"`
+		Clouds     int              `json:"clouds"`
+		Visibility int              `json:"visibility"`
+		WindSpeed  float64          `json:"wind_speed"`
+		WindGust   float64          `json:"wind_gust"`
+		WindDeg    int              `json:"wind_deg"`
+		Rain       *oneHourVolume   `json:"rain,omitempty"`
+		Snow       *oneHourVolume   `json:"snow,omitempty"`
+		Weather    []OneCallWeather `json:"weather"`
 	} `json:"current"`
 	Minutely []struct {
 		Dt            int64   `json:"dt"`
 		Precipitation float64 `json:"precipitation"`
 	} `json:"minutely"`
+	Hourly []OneCallHourly `json:"hourly"`
+	Daily  []OneCallDaily  `json:"daily"`
+	Alerts []OneCallAlert  `json:"alerts"`
 }
 
-// Public DTO for app consumption (server output shape)
+// OneCallWeather is a weather condition; ID is OpenWeather's condition code.
+type OneCallWeather struct {
+	ID          int    `json:"id"`
+	Main        string `json:"main"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// oneHourVolume is the {"1h": mm} precipitation object of current and
+// hourly entries.
+type oneHourVolume struct {
+	OneHour float64 `json:"1h"`
+}
+
+func (v *oneHourVolume) mm() float64 {
+	if v == nil {
+		return 0
+	}
+	return v.OneHour
+}
+
+// OneCallHourly is one hour of the 48-hour forecast.
+type OneCallHourly struct {
+	Dt         int64            `json:"dt"`
+	Temp       float64          `json:"temp"`
+	FeelsLike  float64          `json:"feels_like"`
+	Pressure   int              `json:"pressure"`
+	Humidity   int              `json:"humidity"`
+	DewPoint   float64          `json:"dew_point"`
+	UVI        float64          `json:"uvi"`
+	Clouds     int              `json:"clouds"`
+	Visibility int              `json:"visibility"`
+	WindSpeed  float64          `json:"wind_speed"`
+	WindGust   float64          `json:"wind_gust"`
+	WindDeg    int              `json:"wind_deg"`
+	Pop        float64          `json:"pop"`
+	Rain       *oneHourVolume   `json:"rain,omitempty"`
+	Snow       *oneHourVolume   `json:"snow,omitempty"`
+	Weather    []OneCallWeather `json:"weather"`
+}
+
+// OneCallDaily is one day of the 8-day forecast. Unlike hourly entries,
+// rain and snow are plain millimetre totals.
+type OneCallDaily struct {
+	Dt      int64  `json:"dt"`
+	Sunrise int64  `json:"sunrise"`
+	Sunset  int64  `json:"sunset"`
+	Summary string `json:"summary"`
+	Temp    struct {
+		Day   float64 `json:"day"`
+		Min   float64 `json:"min"`
+		Max   float64 `json:"max"`
+		Night float64 `json:"night"`
+		Eve   float64 `json:"eve"`
+		Morn  float64 `json:"morn"`
+	} `json:"temp"`
+	Humidity  int              `json:"humidity"`
+	DewPoint  float64          `json:"dew_point"`
+	WindSpeed float64          `json:"wind_speed"`
+	WindGust  float64          `json:"wind_gust"`
+	WindDeg   int              `json:"wind_deg"`
+	Clouds    int              `json:"clouds"`
+	UVI       float64          `json:"uvi"`
+	Pop       float64          `json:"pop"`
+	Rain      float64          `json:"rain"`
+	Snow      float64          `json:"snow"`
+	Weather   []OneCallWeather `json:"weather"`
+}
+
+// OneCallAlert is a national weather alert.
+type OneCallAlert struct {
+	SenderName  string   `json:"sender_name"`
+	Event       string   `json:"event"`
+	Start       int64    `json:"start"`
+	End         int64    `json:"end"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// Public DTO for app consumption (server output shape). The suffixed
+// fields are always metric; Temperature and WindSpeed repeat them in Units.
 type WeatherDTO struct {
 	UVIndex         float64 `json:"uvIndex"`
 	TemperatureC    float64 `json:"temperatureC"`
 	HumidityPercent int     `json:"humidityPercent"`
 	Precip10Min     float64 `json:"precip10min"`
+	WindSpeedMS     float64 `json:"windSpeedMs"`
+	// WindGustMS is the strongest gust now or forecast for this hour;
+	// WindDeg is where the wind blows from, clockwise from north.
+	WindGustMS    float64 `json:"windGustMs"`
+	WindDeg       int     `json:"windDeg"`
+	CloudsPercent int     `json:"cloudsPercent"`
+	Temperature   float64 `json:"temperature"`
+	WindSpeed     float64 `json:"windSpeed"`
+	WindGust      float64 `json:"windGust"`
+	Units         Units   `json:"units"`
+	// ObservedAt is when the provider's current conditions apply.
+	ObservedAt time.Time `json:"observedAt"`
+	// Heat is the estimated heat stroke risk; nil without ObservedAt.
+	Heat *HeatRiskDTO `json:"heat,omitempty"`
+	// Provider is the ID of the provider that served the data.
+	Provider string `json:"provider"`
+	DataAge  `json:"-"`
 }
 
-// FetchWeather retrieves weather from OpenWeather and normalizes it for the app.
-func FetchWeather(ctx context.Context, f *FetchController, lat, lon float64, units, lang string) (WeatherDTO, error) {
-	apiKey := os.Getenv("OPENWEATHER_API_KEY")
-	q := map[string]string{
-		"lat":   strconv.FormatFloat(lat, 'f', 6, 64),
-		"lon":   strconv.FormatFloat(lon, 'f', 6, 64),
-		"appid": apiKey,
-	}
-	if units != "" {
-		q["units"] = units
-	}
-	if lang != "" {
-		q["lang"] = lang
-	}
-
-	base := os.Getenv("OPENWEATHER_ONECALL_BASE")
-	if base == "" {
-		base = oneCall3
-	}
-
-	u, err := f.BuildURL(base, q)
+// FetchWeather retrieves current weather from the configured provider and
+// normalizes it for the app, with display values in units.
+func FetchWeather(ctx context.Context, f *FetchController, lat, lon float64, units Units, lang string) (WeatherDTO, error) {
+	p := f.Weather
+	wd, err := p.Current(ctx, f, lat, lon, lang)
 	if err != nil {
+		log.Printf("FetchWeather: %s error: %v", p.ID(), err)
 		return WeatherDTO{}, err
 	}
-
-	var oc OneCallResponse
-	if err := f.GetJSON(ctx, u, nil, &oc); err != nil {
-		log.Printf("FetchWeather: GetJSON error: %v", err)
-		return WeatherDTO{}, err
-	}
-
-	// Determine precipitation 10 minutes later from minutely data
-	target := oc.Current.Dt + 10*60
-	var precip10 float64
-	var picked bool
-	for _, m := range oc.Minutely {
-		if m.Dt >= target {
-			precip10 = m.Precipitation
-			picked = true
-			break
-		}
-	}
-	if !picked && len(oc.Minutely) > 0 {
-		precip10 = oc.Minutely[len(oc.Minutely)-1].Precipitation
-	}
-
-	wd := WeatherDTO{
-		UVIndex:         oc.Current.UVI,
-		TemperatureC:    oc.Current.Temp, // expects units=metric for Celsius
-		HumidityPercent: oc.Current.Humidity,
-		Precip10Min:     precip10,
-	}
-	return wd, nil
+	wd.Heat = estimateHeatRisk(wd, lat, lon)
+	return wd.In(units), nil
 }
@@ -6,7 +6,6 @@ import (
     "net/http"
     "net/http/httptest"
     "net/url"
-    "os"
     "testing"
     "time"
 )
@@ -86,7 +85,7 @@ func setupTestFetch(t *testing.T, body []byte, expectPath string) (*FetchControl
         Timeout:   5 * time.Second,
         Transport: &rewriteTransport{base: u, rt: http.DefaultTransport},
     }
-    os.Setenv("OPENWEATHER_API_KEY", "testkey")
+    fc.Weather = &OpenWeatherProvider{APIKey: "testkey"}
     cleanup := func() { srv.Close() }
     return fc, cleanup
 }
@@ -102,7 +101,7 @@ func TestFetchWeather_Precip10Min_Exact(t *testing.T) {
     fetch, done := setupTestFetch(t, body, "/data/3.0/onecall")
     defer done()
 
-    dto, err := FetchWeather(context.Background(), fetch, 35.0, 139.0, "metric", "ja")
+    dto, err := FetchWeather(context.Background(), fetch, 35.0, 139.0, MetricUnits, "ja")
     if err != nil {
         t.Fatalf("FetchWeather error: %v", err)
     }
@@ -124,7 +123,7 @@ func TestFetchWeather_Precip10Min_FallbackLast(t *testing.T) {
     fetch, done := setupTestFetch(t, body, "/data/3.0/onecall")
     defer done()
 
-    dto, err := FetchWeather(context.Background(), fetch, 51.5, -0.1, "metric", "en")
+    dto, err := FetchWeather(context.Background(), fetch, 51.5, -0.1, MetricUnits, "en")
     if err != nil {
         t.Fatalf("FetchWeather error: %v", err)
     }
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FailoverPolicy configures demotion of failing weather providers.
+type FailoverPolicy struct {
+	// Window is how many recent requests a provider's error rate covers;
+	// zero disables demotion.
+	Window int
+	// MinRequests in the window are needed before a provider is judged.
+	MinRequests int
+	// MaxErrorRate above which a provider is demoted.
+	MaxErrorRate float64
+	// Demotion is how long a demoted provider is tried after the others.
+	Demotion time.Duration
+}
+
+// DefaultFailoverPolicy demotes a provider failing most of its last few
+// requests for long enough to ride out a typical outage.
+var DefaultFailoverPolicy = FailoverPolicy{Window: 10, MinRequests: 3, MaxErrorRate: 0.5, Demotion: 5 * time.Minute}
+
+// FailoverProvider tries weather providers in priority order and returns
+// the first success. Providers demoted under Policy move to the back of
+// the order until their demotion ends.
+type FailoverProvider struct {
+	Providers []WeatherProvider
+	Policy    FailoverPolicy
+
+	health providerHealthSet
+}
+
+// NewFailoverProvider fails over between ps under DefaultFailoverPolicy.
+func NewFailoverProvider(ps ...WeatherProvider) *FailoverProvider {
+	return &FailoverProvider{Providers: ps, Policy: DefaultFailoverPolicy}
+}
+
+func (p *FailoverProvider) ID() string {
+	ids := make([]string, len(p.Providers))
+	for i, wp := range p.Providers {
+		ids[i] = wp.ID()
+	}
+	return strings.Join(ids, ",")
+}
+
+func (p *FailoverProvider) Current(ctx context.Context, f *FetchController, lat, lon float64, lang string) (WeatherDTO, error) {
+	return failover(ctx, f, p, func(wp WeatherProvider) (WeatherDTO, error) {
+		return wp.Current(ctx, f, lat, lon, lang)
+	})
+}
+
+func (p *FailoverProvider) Forecast(ctx context.Context, f *FetchController, lat, lon float64, lang string) (ForecastDTO, error) {
+	return failover(ctx, f, p, func(wp WeatherProvider) (ForecastDTO, error) {
+		return wp.Forecast(ctx, f, lat, lon, lang)
+	})
+}
+
+func (p *FailoverProvider) Minutely(ctx context.Context, f *FetchController, lat, lon float64) (PrecipSeries, error) {
+	return failover(ctx, f, p, func(wp WeatherProvider) (PrecipSeries, error) {
+		return wp.Minutely(ctx, f, lat, lon)
+	})
+}
+
+// failover calls providers in health order until one succeeds, recording
+// each outcome.
+func failover[T any](ctx context.Context, f *FetchController, p *FailoverProvider, call func(WeatherProvider) (T, error)) (T, error) {
+	var zero T
+	var errs []error
+	for _, wp := range p.health.order(f.now(), p.Providers) {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		v, err := call(wp)
+		p.health.record(f.now(), p.Policy, wp.ID(), err)
+		if err == nil {
+			return v, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", wp.ID(), err))
+	}
+	return zero, errors.Join(errs...)
+}
+
+// WeatherProviderStatus is a snapshot of one weather provider's health.
+type WeatherProviderStatus struct {
+	ID string `json:"id"`
+	// Requests and Errors cover the policy window.
+	Requests     int        `json:"requests"`
+	Errors       int        `json:"errors"`
+	ErrorRate    float64    `json:"errorRate"`
+	DemotedUntil *time.Time `json:"demotedUntil,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+}
+
+type providerHealth struct {
+	outcomes     []bool // recent requests, true for failure; oldest first
+	demotedUntil time.Time
+	lastError    string
+}
+
+func (h *providerHealth) errors() int {
+	n := 0
+	for _, failed := range h.outcomes {
+		if failed {
+			n++
+		}
+	}
+	return n
+}
+
+// providerHealthSet tracks providers by ID; the zero value is ready.
+type providerHealthSet struct {
+	mu        sync.Mutex
+	providers map[string]*providerHealth
+}
+
+// order returns ps with currently demoted providers moved to the back,
+// keeping priority order within each part.
+func (s *providerHealthSet) order(now time.Time, ps []WeatherProvider) []WeatherProvider {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]WeatherProvider, 0, len(ps))
+	var demoted []WeatherProvider
+	for _, wp := range ps {
+		if h, ok := s.providers[wp.ID()]; ok && now.Before(h.demotedUntil) {
+			demoted = append(demoted, wp)
+			continue
+		}
+		out = append(out, wp)
+	}
+	return append(out, demoted...)
+}
+
+// record adds a request outcome and demotes the provider when its error
+// rate goes over the limit. The window restarts on demotion so that the
+// provider is judged afresh once it is back in front.
+func (s *providerHealthSet) record(now time.Time, pol FailoverPolicy, id string, err error) {
+	if pol.Window <= 0 || errors.Is(err, context.Canceled) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.providers == nil {
+		s.providers = map[string]*providerHealth{}
+	}
+	h, ok := s.providers[id]
+	if !ok {
+		h = &providerHealth{}
+		s.providers[id] = h
+	}
+	h.outcomes = append(h.outcomes, err != nil)
+	if len(h.outcomes) > pol.Window {
+		h.outcomes = h.outcomes[len(h.outcomes)-pol.Window:]
+	}
+	if err == nil {
+		return
+	}
+	h.lastError = err.Error()
+	n := len(h.outcomes)
+	if n >= pol.MinRequests && float64(h.errors())/float64(n) > pol.MaxErrorRate {
+		h.demotedUntil = now.Add(pol.Demotion)
+		h.outcomes = nil
+	}
+}
+
+// WeatherProviderStates returns a snapshot of the health of every weather
+// provider the failover has used, by ID; empty without failover.
+func (f *FetchController) WeatherProviderStates() []WeatherProviderStatus {
+	p, ok := f.Weather.(*FailoverProvider)
+	if !ok {
+		return []WeatherProviderStatus{}
+	}
+	return p.health.states(f.now())
+}
+
+func (s *providerHealthSet) states(now time.Time) []WeatherProviderStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]WeatherProviderStatus, 0, len(s.providers))
+	for id, h := range s.providers {
+		st := WeatherProviderStatus{ID: id, Requests: len(h.outcomes), Errors: h.errors(), LastError: h.lastError}
+		if st.Requests > 0 {
+			st.ErrorRate = float64(st.Errors) / float64(st.Requests)
+		}
+		if now.Before(h.demotedUntil) {
+			until := h.demotedUntil
+			st.DemotedUntil = &until
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stubWeather is a provider failing while fail is set.
+type stubWeather struct {
+	id    string
+	fail  bool
+	calls int
+}
+
+func (s *stubWeather) ID() string { return s.id }
+
+func (s *stubWeather) Current(ctx context.Context, f *FetchController, lat, lon float64, lang string) (WeatherDTO, error) {
+	s.calls++
+	if s.fail {
+		return WeatherDTO{}, errors.New("upstream down")
+	}
+	return WeatherDTO{Provider: s.id}, nil
+}
+
+func (s *stubWeather) Forecast(ctx context.Context, f *FetchController, lat, lon float64, lang string) (ForecastDTO, error) {
+	s.calls++
+	if s.fail {
+		return ForecastDTO{}, errors.New("upstream down")
+	}
+	return ForecastDTO{Provider: s.id}, nil
+}
+
+func (s *stubWeather) Minutely(ctx context.Context, f *FetchController, lat, lon float64) (PrecipSeries, error) {
+	s.calls++
+	if s.fail {
+		return PrecipSeries{}, errors.New("upstream down")
+	}
+	return PrecipSeries{Provider: s.id}, nil
+}
+
+func TestFailoverProvider_DemotesFailingPrimary(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	fc := NewFetchController()
+	fc.now = func() time.Time { return now }
+
+	primary, backup := &stubWeather{id: "a", fail: true}, &stubWeather{id: "b"}
+	p := &FailoverProvider{
+		Providers: []WeatherProvider{primary, backup},
+		Policy:    FailoverPolicy{Window: 4, MinRequests: 2, MaxErrorRate: 0.5, Demotion: time.Minute},
+	}
+	fc.Weather = p
+	current := func() WeatherDTO {
+		t.Helper()
+		wd, err := p.Current(context.Background(), fc, 0, 0, "")
+		if err != nil {
+			t.Fatalf("Current error: %v", err)
+		}
+		return wd
+	}
+
+	// The backup serves while the primary fails; two failures demote it.
+	for i := 0; i < 2; i++ {
+		if wd := current(); wd.Provider != "b" {
+			t.Fatalf("expected backup to serve, got %q", wd.Provider)
+		}
+	}
+	st := fc.WeatherProviderStates()
+	if len(st) != 2 || st[0].ID != "a" || st[0].DemotedUntil == nil || st[0].LastError == "" {
+		t.Fatalf("expected primary demoted: %+v", st)
+	}
+
+	// While demoted, the primary is not tried first.
+	primary.fail = false
+	if wd := current(); wd.Provider != "b" || primary.calls != 2 {
+		t.Fatalf("expected demoted primary skipped, served by %q after %d calls", wd.Provider, primary.calls)
+	}
+
+	// After the demotion the primary is back in front.
+	now = now.Add(time.Minute)
+	if wd := current(); wd.Provider != "a" {
+		t.Fatalf("expected primary after demotion, got %q", wd.Provider)
+	}
+}
+
+func TestFailoverProvider_AllFail(t *testing.T) {
+	fc := NewFetchController()
+	p := &FailoverProvider{Providers: []WeatherProvider{&stubWeather{id: "a", fail: true}, &stubWeather{id: "b", fail: true}}}
+	if _, err := p.Current(context.Background(), fc, 0, 0, ""); err == nil {
+		t.Fatalf("expected an error when every provider fails")
+	}
+}
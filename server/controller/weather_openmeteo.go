@@ -0,0 +1,293 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+)
+
+const openMeteoForecast = "https://api.open-meteo.com/v1/forecast"
+
+// Open-Meteo models update every 15 minutes at best.
+var openMeteoPolicy = CachePolicy{TTL: 5 * time.Minute, MaxStale: 30 * time.Minute}
+
+// OpenMeteoProvider reads the keyless Open-Meteo forecast API.
+type OpenMeteoProvider struct {
+	// BaseURL is the forecast endpoint; empty means the public one.
+	BaseURL string
+}
+
+// openMeteoFromEnv configures Open-Meteo from OPENMETEO_FORECAST_BASE.
+func openMeteoFromEnv() *OpenMeteoProvider {
+	return &OpenMeteoProvider{BaseURL: os.Getenv("OPENMETEO_FORECAST_BASE")}
+}
+
+func (p *OpenMeteoProvider) ID() string { return WeatherOpenMeteo }
+
+// openMeteoResponse models the parts of /v1/forecast requested by
+// Current, Forecast and Minutely, with timeformat=unixtime.
+type openMeteoResponse struct {
+	Latitude         float64 `json:"latitude"`
+	Longitude        float64 `json:"longitude"`
+	Timezone         string  `json:"timezone"`
+	UTCOffsetSeconds int     `json:"utc_offset_seconds"`
+	Current          struct {
+		Time             int64   `json:"time"`
+		Interval         int     `json:"interval"`
+		Temperature2m    float64 `json:"temperature_2m"`
+		RelativeHumidity float64 `json:"relative_humidity_2m"`
+		WindSpeed10m     float64 `json:"wind_speed_10m"`
+		CloudCover       float64 `json:"cloud_cover"`
+		WindDirection10m float64 `json:"wind_direction_10m"`
+		WindGusts10m     float64 `json:"wind_gusts_10m"`
+		UVIndex          float64 `json:"uv_index"`
+	} `json:"current"`
+	Hourly struct {
+		Time                     []int64   `json:"time"`
+		Temperature2m            []float64 `json:"temperature_2m"`
+		ApparentTemperature      []float64 `json:"apparent_temperature"`
+		RelativeHumidity         []float64 `json:"relative_humidity_2m"`
+		UVIndex                  []float64 `json:"uv_index"`
+		WindSpeed10m             []float64 `json:"wind_speed_10m"`
+		WindGusts10m             []float64 `json:"wind_gusts_10m"`
+		WindDirection10m         []float64 `json:"wind_direction_10m"`
+		PrecipitationProbability []float64 `json:"precipitation_probability"`
+		Precipitation            []float64 `json:"precipitation"`
+		WeatherCode              []int     `json:"weather_code"`
+		IsDay                    []int     `json:"is_day"`
+	} `json:"hourly"`
+	Daily struct {
+		Time                        []int64   `json:"time"`
+		WeatherCode                 []int     `json:"weather_code"`
+		Temperature2mMax            []float64 `json:"temperature_2m_max"`
+		Temperature2mMin            []float64 `json:"temperature_2m_min"`
+		PrecipitationProbabilityMax []float64 `json:"precipitation_probability_max"`
+		PrecipitationSum            []float64 `json:"precipitation_sum"`
+		UVIndexMax                  []float64 `json:"uv_index_max"`
+		WindSpeed10mMax             []float64 `json:"wind_speed_10m_max"`
+		Sunrise                     []int64   `json:"sunrise"`
+		Sunset                      []int64   `json:"sunset"`
+	} `json:"daily"`
+	// Minutely15 precipitation is the sum (mm) over the 15 minutes
+	// preceding each time.
+	Minutely15 struct {
+		Time          []int64   `json:"time"`
+		Precipitation []float64 `json:"precipitation"`
+	} `json:"minutely_15"`
+}
+
+// Current ignores lang; Open-Meteo returns no text.
+func (p *OpenMeteoProvider) Current(ctx context.Context, f *FetchController, lat, lon float64, lang string) (WeatherDTO, error) {
+	q := map[string]string{
+		"current":              "temperature_2m,relative_humidity_2m,wind_speed_10m,uv_index,cloud_cover,wind_direction_10m,wind_gusts_10m",
+		"minutely_15":          "precipitation",
+		"forecast_minutely_15": "2",
+	}
+
+	om, meta, err := p.get(ctx, f, lat, lon, q)
+	if err != nil {
+		return WeatherDTO{}, err
+	}
+
+	// The first quarter hour ending 10 minutes from now or later; its
+	// total is scaled to mm/h to match One Call's minutely intensity.
+	target := om.Current.Time + 10*60
+	var precip10 float64
+	times, precip := om.Minutely15.Time, om.Minutely15.Precipitation
+	for i := 0; i < len(times) && i < len(precip); i++ {
+		precip10 = precip[i] * 4
+		if times[i] >= target {
+			break
+		}
+	}
+
+	return WeatherDTO{
+		UVIndex:         om.Current.UVIndex,
+		TemperatureC:    om.Current.Temperature2m,
+		HumidityPercent: int(om.Current.RelativeHumidity + 0.5),
+		Precip10Min:     precip10,
+		WindSpeedMS:     om.Current.WindSpeed10m,
+		WindGustMS:      max(om.Current.WindGusts10m, om.Current.WindSpeed10m),
+		WindDeg:         int(om.Current.WindDirection10m + 0.5),
+		CloudsPercent:   int(om.Current.CloudCover + 0.5),
+		ObservedAt:      unix(om.Current.Time),
+		Provider:        p.ID(),
+		DataAge:         dataAge(meta),
+	}, nil
+}
+
+// get requests /v1/forecast for a point with the variables in q, in
+// metric units and Unix times.
+func (p *OpenMeteoProvider) get(ctx context.Context, f *FetchController, lat, lon float64, q map[string]string) (openMeteoResponse, FetchMeta, error) {
+	var om openMeteoResponse
+	q["latitude"] = strconv.FormatFloat(lat, 'f', 6, 64)
+	q["longitude"] = strconv.FormatFloat(lon, 'f', 6, 64)
+	q["timeformat"] = "unixtime"
+	q["timezone"] = "auto"
+	q["wind_speed_unit"] = "ms"
+
+	base := p.BaseURL
+	if base == "" {
+		base = openMeteoForecast
+	}
+	u, err := f.BuildURL(base, q)
+	if err != nil {
+		return om, FetchMeta{}, err
+	}
+	meta, err := f.GetJSONCached(ctx, u, nil, openMeteoPolicy, &om)
+	return om, meta, err
+}
+
+// Forecast ignores lang: condition descriptions are OpenWeather's English
+// ones, and Open-Meteo has no alerts or daily summaries.
+func (p *OpenMeteoProvider) Forecast(ctx context.Context, f *FetchController, lat, lon float64, lang string) (ForecastDTO, error) {
+	// current is requested only for its time.
+	om, meta, err := p.get(ctx, f, lat, lon, map[string]string{
+		"current":        "is_day",
+		"hourly":         "temperature_2m,apparent_temperature,relative_humidity_2m,uv_index,wind_speed_10m,wind_gusts_10m,wind_direction_10m,precipitation_probability,precipitation,weather_code,is_day",
+		"daily":          "weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max,precipitation_sum,uv_index_max,wind_speed_10m_max,sunrise,sunset",
+		"forecast_days":  "8",
+		"forecast_hours": strconv.Itoa(forecastHours),
+	})
+	if err != nil {
+		return ForecastDTO{}, err
+	}
+
+	out := ForecastDTO{
+		Timezone: om.Timezone,
+		Hourly:   []HourlyDTO{},
+		Daily:    []DailyDTO{},
+		Alerts:   []AlertDTO{},
+		Provider: p.ID(),
+		DataAge:  dataAge(meta),
+	}
+	// at reads v[i], tolerating variables missing from the response.
+	at := func(v []float64, i int) float64 {
+		if i < len(v) {
+			return v[i]
+		}
+		return 0
+	}
+	code := func(v []int, i int) int {
+		if i < len(v) {
+			return v[i]
+		}
+		return -1
+	}
+
+	// Hourly entries start at the top of the current hour.
+	h := om.Hourly
+	for i, t := range h.Time {
+		if t+3600 <= om.Current.Time {
+			continue
+		}
+		if len(out.Hourly) == forecastHours {
+			break
+		}
+		out.Hourly = append(out.Hourly, HourlyDTO{
+			Time:              unix(t),
+			TemperatureC:      at(h.Temperature2m, i),
+			FeelsLikeC:        at(h.ApparentTemperature, i),
+			HumidityPercent:   int(at(h.RelativeHumidity, i) + 0.5),
+			UVIndex:           at(h.UVIndex, i),
+			WindSpeedMS:       at(h.WindSpeed10m, i),
+			WindGustMS:        at(h.WindGusts10m, i),
+			WindDeg:           int(at(h.WindDirection10m, i) + 0.5),
+			PrecipProbability: at(h.PrecipitationProbability, i) / 100,
+			PrecipMM:          at(h.Precipitation, i),
+			Condition:         wmoCondition(code(h.WeatherCode, i), i >= len(h.IsDay) || h.IsDay[i] == 1),
+		})
+	}
+
+	d := om.Daily
+	for i, t := range d.Time {
+		day := DailyDTO{
+			Date:              unix(t),
+			TempMinC:          at(d.Temperature2mMin, i),
+			TempMaxC:          at(d.Temperature2mMax, i),
+			PrecipProbability: at(d.PrecipitationProbabilityMax, i) / 100,
+			PrecipMM:          at(d.PrecipitationSum, i),
+			UVIndex:           at(d.UVIndexMax, i),
+			WindSpeedMS:       at(d.WindSpeed10mMax, i),
+			Condition:         wmoCondition(code(d.WeatherCode, i), true),
+		}
+		if i < len(d.Sunrise) && i < len(d.Sunset) {
+			day.Sunrise, day.Sunset = unix(d.Sunrise[i]), unix(d.Sunset[i])
+		}
+		out.Daily = append(out.Daily, day)
+	}
+	if len(out.Daily) > 0 {
+		out.Sunrise, out.Sunset = out.Daily[0].Sunrise, out.Daily[0].Sunset
+	}
+	return out, nil
+}
+
+// Minutely spreads each quarter hour's total evenly over its minutes, in
+// mm/h like One Call's minutely intensity, for about the next hour.
+func (p *OpenMeteoProvider) Minutely(ctx context.Context, f *FetchController, lat, lon float64) (PrecipSeries, error) {
+	om, meta, err := p.get(ctx, f, lat, lon, map[string]string{
+		"minutely_15":          "precipitation",
+		"forecast_minutely_15": "5",
+	})
+	if err != nil {
+		return PrecipSeries{}, err
+	}
+
+	out := PrecipSeries{Minutes: []MinutePrecip{}, Provider: p.ID(), DataAge: dataAge(meta)}
+	times, precip := om.Minutely15.Time, om.Minutely15.Precipitation
+	for i := 0; i < len(times) && i < len(precip); i++ {
+		end := unix(times[i])
+		for m := 15; m > 0; m-- {
+			out.Minutes = append(out.Minutes, MinutePrecip{Time: end.Add(-time.Duration(m) * time.Minute), MMPerHour: precip[i] * 4})
+		}
+	}
+	return out, nil
+}
+
+// wmoConditions maps WMO weather interpretation codes, as used by
+// Open-Meteo, onto OpenWeather's condition codes; icons lack the d/n
+// suffix.
+var wmoConditions = map[int]ConditionDTO{
+	0:  {800, "Clear", "clear sky", "01"},
+	1:  {801, "Clouds", "few clouds", "02"},
+	2:  {802, "Clouds", "scattered clouds", "03"},
+	3:  {804, "Clouds", "overcast clouds", "04"},
+	45: {741, "Fog", "fog", "50"},
+	48: {741, "Fog", "fog", "50"},
+	51: {300, "Drizzle", "light intensity drizzle", "09"},
+	53: {301, "Drizzle", "drizzle", "09"},
+	55: {302, "Drizzle", "heavy intensity drizzle", "09"},
+	56: {511, "Rain", "freezing rain", "13"},
+	57: {511, "Rain", "freezing rain", "13"},
+	61: {500, "Rain", "light rain", "10"},
+	63: {501, "Rain", "moderate rain", "10"},
+	65: {502, "Rain", "heavy intensity rain", "10"},
+	66: {511, "Rain", "freezing rain", "13"},
+	67: {511, "Rain", "freezing rain", "13"},
+	71: {600, "Snow", "light snow", "13"},
+	73: {601, "Snow", "snow", "13"},
+	75: {602, "Snow", "heavy snow", "13"},
+	77: {600, "Snow", "light snow", "13"},
+	80: {520, "Rain", "light intensity shower rain", "09"},
+	81: {521, "Rain", "shower rain", "09"},
+	82: {522, "Rain", "heavy intensity shower rain", "09"},
+	85: {620, "Snow", "light shower snow", "13"},
+	86: {621, "Snow", "shower snow", "13"},
+	95: {211, "Thunderstorm", "thunderstorm", "11"},
+	96: {201, "Thunderstorm", "thunderstorm with rain", "11"},
+	99: {202, "Thunderstorm", "thunderstorm with heavy rain", "11"},
+}
+
+func wmoCondition(code int, day bool) *ConditionDTO {
+	c, ok := wmoConditions[code]
+	if !ok {
+		return nil
+	}
+	if day {
+		c.Icon += "d"
+	} else {
+		c.Icon += "n"
+	}
+	return &c
+}
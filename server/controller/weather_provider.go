@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WeatherProvider is an upstream source of weather. Every method returns
+// metric units; lang localizes text where supported.
+type WeatherProvider interface {
+	ID() string
+	// Current returns conditions at a point.
+	Current(ctx context.Context, f *FetchController, lat, lon float64, lang string) (WeatherDTO, error)
+	// Forecast returns the hourly and daily forecast and active alerts.
+	Forecast(ctx context.Context, f *FetchController, lat, lon float64, lang string) (ForecastDTO, error)
+	// Minutely returns near-term precipitation, one entry per minute.
+	Minutely(ctx context.Context, f *FetchController, lat, lon float64) (PrecipSeries, error)
+}
+
+// Weather provider IDs, as accepted by WEATHER_PROVIDER.
+const (
+	WeatherOpenWeather = "openweather"
+	WeatherOpenMeteo   = "openmeteo"
+)
+
+// weatherProviderFromEnv returns the providers named by WEATHER_PROVIDER,
+// a comma-separated list in priority order; more than one makes a
+// FailoverProvider. When it is unset, OpenWeather backed by the keyless
+// Open-Meteo is used if OPENWEATHER_API_KEY is set, and Open-Meteo alone
+// otherwise.
+func weatherProviderFromEnv() WeatherProvider {
+	var ps []WeatherProvider
+	for _, id := range splitIDs(strings.ToLower(os.Getenv("WEATHER_PROVIDER"))) {
+		switch id {
+		case WeatherOpenWeather:
+			ps = append(ps, openWeatherFromEnv())
+		case WeatherOpenMeteo:
+			ps = append(ps, openMeteoFromEnv())
+		default:
+			log.Printf("[warn] ignoring unknown WEATHER_PROVIDER entry %q", id)
+		}
+	}
+	if len(ps) == 0 {
+		if os.Getenv("OPENWEATHER_API_KEY") != "" {
+			ps = append(ps, openWeatherFromEnv())
+		}
+		ps = append(ps, openMeteoFromEnv())
+	}
+	if len(ps) == 1 {
+		return ps[0]
+	}
+	return NewFailoverProvider(ps...)
+}
+
+const oneCall3 = "https://api.openweathermap.org/data/3.0/onecall"
+
+// errNoOpenWeatherKey is returned instead of calling One Call without a key.
+var errNoOpenWeatherKey = errors.New("openweather: OPENWEATHER_API_KEY not set")
+
+// OpenWeatherProvider reads One Call API 3.0.
+type OpenWeatherProvider struct {
+	APIKey string
+	// BaseURL is the One Call endpoint; empty means the public one.
+	BaseURL string
+}
+
+// openWeatherFromEnv configures OpenWeather from OPENWEATHER_API_KEY and
+// OPENWEATHER_ONECALL_BASE.
+func openWeatherFromEnv() *OpenWeatherProvider {
+	return &OpenWeatherProvider{
+		APIKey:  os.Getenv("OPENWEATHER_API_KEY"),
+		BaseURL: os.Getenv("OPENWEATHER_ONECALL_BASE"),
+	}
+}
+
+func (p *OpenWeatherProvider) ID() string { return WeatherOpenWeather }
+
+func (p *OpenWeatherProvider) oneCall(ctx context.Context, f *FetchController, lat, lon float64, lang string) (OneCallResponse, FetchMeta, error) {
+	var oc OneCallResponse
+	if p.APIKey == "" {
+		return oc, FetchMeta{}, errNoOpenWeatherKey
+	}
+	q := map[string]string{
+		"lat":   strconv.FormatFloat(lat, 'f', 6, 64),
+		"lon":   strconv.FormatFloat(lon, 'f', 6, 64),
+		"appid": p.APIKey,
+		"units": UnitsMetric,
+	}
+	if lang != "" {
+		q["lang"] = lang
+	}
+
+	base := p.BaseURL
+	if base == "" {
+		base = oneCall3
+	}
+	u, err := f.BuildURL(base, q)
+	if err != nil {
+		return oc, FetchMeta{}, err
+	}
+	meta, err := f.GetJSONCached(ctx, u, nil, oneCallPolicy, &oc)
+	return oc, meta, err
+}
+
+func (p *OpenWeatherProvider) Current(ctx context.Context, f *FetchController, lat, lon float64, lang string) (WeatherDTO, error) {
+	oc, meta, err := p.oneCall(ctx, f, lat, lon, lang)
+	if err != nil {
+		return WeatherDTO{}, err
+	}
+
+	// Determine precipitation 10 minutes later from minutely data
+	target := oc.Current.Dt + 10*60
+	var precip10 float64
+	var picked bool
+	for _, m := range oc.Minutely {
+		if m.Dt >= target {
+			precip10 = m.Precipitation
+			picked = true
+			break
+		}
+	}
+	if !picked && len(oc.Minutely) > 0 {
+		precip10 = oc.Minutely[len(oc.Minutely)-1].Precipitation
+	}
+
+	// Gusts are often only in the hourly forecast.
+	gust := oc.Current.WindGust
+	for _, h := range oc.Hourly {
+		if h.Dt <= oc.Current.Dt && oc.Current.Dt < h.Dt+3600 {
+			gust = max(gust, h.WindGust)
+		}
+	}
+
+	return WeatherDTO{
+		UVIndex:         oc.Current.UVI,
+		TemperatureC:    oc.Current.Temp,
+		HumidityPercent: oc.Current.Humidity,
+		Precip10Min:     precip10,
+		WindSpeedMS:     oc.Current.WindSpeed,
+		WindGustMS:      max(gust, oc.Current.WindSpeed),
+		WindDeg:         oc.Current.WindDeg,
+		CloudsPercent:   oc.Current.Clouds,
+		ObservedAt:      unix(oc.Current.Dt),
+		Provider:        p.ID(),
+		DataAge:         dataAge(meta),
+	}, nil
+}
+
+func (p *OpenWeatherProvider) Forecast(ctx context.Context, f *FetchController, lat, lon float64, lang string) (ForecastDTO, error) {
+	oc, meta, err := p.oneCall(ctx, f, lat, lon, lang)
+	if err != nil {
+		return ForecastDTO{}, err
+	}
+	fc := newForecastDTO(oc, meta)
+	fc.Provider = p.ID()
+	return fc, nil
+}
+
+func (p *OpenWeatherProvider) Minutely(ctx context.Context, f *FetchController, lat, lon float64) (PrecipSeries, error) {
+	oc, meta, err := p.oneCall(ctx, f, lat, lon, "")
+	if err != nil {
+		return PrecipSeries{}, err
+	}
+	out := PrecipSeries{Minutes: make([]MinutePrecip, 0, len(oc.Minutely)), Provider: p.ID(), DataAge: dataAge(meta)}
+	for _, m := range oc.Minutely {
+		out.Minutes = append(out.Minutes, MinutePrecip{Time: unix(m.Dt), MMPerHour: m.Precipitation})
+	}
+	return out, nil
+}
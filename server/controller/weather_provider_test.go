@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// fixtureServer serves a file from testdata, recording the last query.
+func fixtureServer(t *testing.T, name string) (*httptest.Server, *http.Request) {
+	t.Helper()
+	body, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	last := &http.Request{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = *r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, last
+}
+
+func TestOpenMeteoProvider_Current(t *testing.T) {
+	srv, req := fixtureServer(t, "openmeteo_current.json")
+	p := &OpenMeteoProvider{BaseURL: srv.URL}
+
+	wd, err := p.Current(context.Background(), NewFetchController(), 35.8, 139.56, "ja")
+	if err != nil {
+		t.Fatalf("Current error: %v", err)
+	}
+	if q := req.URL.Query(); q.Get("wind_speed_unit") != "ms" || q.Get("timeformat") != "unixtime" || q.Get("latitude") == "" ||
+		q.Get("forecast_minutely_15") != "2" || q.Has("forecast_hours") {
+		t.Fatalf("unexpected query: %s", req.URL.RawQuery)
+	}
+	if wd.TemperatureC != 24.3 || wd.HumidityPercent != 71 || wd.WindSpeedMS != 3.4 || wd.UVIndex != 5.15 || wd.Provider != WeatherOpenMeteo ||
+		wd.WindDeg != 183 || wd.WindGustMS != 7.9 || wd.CloudsPercent != 64 {
+		t.Fatalf("unexpected current: %+v", wd)
+	}
+	// The quarter hour ending 10 minutes from now holds 0.3 mm: 1.2 mm/h.
+	if wd.Precip10Min != 1.2 {
+		t.Fatalf("unexpected precip10min: got %.2f want 1.2", wd.Precip10Min)
+	}
+}
+
+func TestOpenWeatherProvider_Current(t *testing.T) {
+	srv, req := fixtureServer(t, "onecall_current.json")
+	p := &OpenWeatherProvider{APIKey: "testkey", BaseURL: srv.URL}
+
+	wd, err := p.Current(context.Background(), NewFetchController(), 35.8, 139.56, "ja")
+	if err != nil {
+		t.Fatalf("Current error: %v", err)
+	}
+	if q := req.URL.Query(); q.Get("appid") != "testkey" || q.Get("units") != "metric" || q.Get("lang") != "ja" {
+		t.Fatalf("unexpected query: %s", req.URL.RawQuery)
+	}
+	if wd.TemperatureC != 24.1 || wd.HumidityPercent != 72 || wd.WindSpeedMS != 3.6 || wd.UVIndex != 5.2 || wd.Precip10Min != 0.31 {
+		t.Fatalf("unexpected current: %+v", wd)
+	}
+	// The hourly gust beats the current one.
+	if wd.WindDeg != 160 || wd.WindGustMS != 8.4 {
+		t.Fatalf("unexpected current: %+v", wd)
+	}
+
+	// Without a key no request is made.
+	if _, err := (&OpenWeatherProvider{BaseURL: srv.URL}).Current(context.Background(), NewFetchController(), 35.8, 139.56, ""); err != errNoOpenWeatherKey {
+		t.Fatalf("expected errNoOpenWeatherKey, got %v", err)
+	}
+}
+
+func TestWeatherProvider_Selection(t *testing.T) {
+	for _, tc := range []struct{ provider, key, want string }{
+		{"", "", WeatherOpenMeteo},
+		{"", "k", "openweather,openmeteo"},
+		{"openmeteo", "k", WeatherOpenMeteo},
+		{"OpenWeather", "", WeatherOpenWeather},
+		{"openmeteo, openweather", "", "openmeteo,openweather"},
+		{"bogus", "", WeatherOpenMeteo},
+	} {
+		t.Setenv("WEATHER_PROVIDER", tc.provider)
+		t.Setenv("OPENWEATHER_API_KEY", tc.key)
+		if got := weatherProviderFromEnv().ID(); got != tc.want {
+			t.Errorf("WEATHER_PROVIDER=%q key=%q: got %s want %s", tc.provider, tc.key, got, tc.want)
+		}
+	}
+}
+
+func TestOpenMeteoProvider_Forecast(t *testing.T) {
+	srv, req := fixtureServer(t, "openmeteo_forecast.json")
+	p := &OpenMeteoProvider{BaseURL: srv.URL}
+
+	fc, err := p.Forecast(context.Background(), NewFetchController(), 35.8, 139.56, "ja")
+	if err != nil {
+		t.Fatalf("Forecast error: %v", err)
+	}
+	if q := req.URL.Query(); q.Get("hourly") == "" || q.Get("daily") == "" || q.Get("wind_speed_unit") != "ms" {
+		t.Fatalf("unexpected query: %s", req.URL.RawQuery)
+	}
+	if fc.Provider != WeatherOpenMeteo || fc.Timezone != "Asia/Tokyo" || len(fc.Hourly) != 3 || len(fc.Daily) != 2 || fc.Alerts == nil {
+		t.Fatalf("unexpected forecast: %+v", fc)
+	}
+	h := fc.Hourly[1]
+	if h.TemperatureC != 25.1 || h.HumidityPercent != 68 || h.WindDeg != 190 || h.PrecipProbability != 0.45 || h.PrecipMM != 0.4 {
+		t.Fatalf("unexpected hour: %+v", h)
+	}
+	// WMO 61 is OpenWeather's light rain.
+	if c := h.Condition; c == nil || c.ID != 500 || c.Main != "Rain" || c.Icon != "10d" {
+		t.Fatalf("unexpected condition: %+v", h.Condition)
+	}
+	d := fc.Daily[0]
+	if d.TempMaxC != 27.2 || d.PrecipProbability != 0.45 || d.Sunrise.Unix() != 1780256040 || !fc.Sunrise.Equal(d.Sunrise) {
+		t.Fatalf("unexpected day: %+v", d)
+	}
+}
+
+func TestOpenMeteoProvider_Minutely(t *testing.T) {
+	srv, _ := fixtureServer(t, "openmeteo_minutely.json")
+	p := &OpenMeteoProvider{BaseURL: srv.URL}
+
+	ps, err := p.Minutely(context.Background(), NewFetchController(), 35.8, 139.56)
+	if err != nil {
+		t.Fatalf("Minutely error: %v", err)
+	}
+	if ps.Provider != WeatherOpenMeteo || len(ps.Minutes) != 5*15 {
+		t.Fatalf("unexpected series: %s, %d minutes", ps.Provider, len(ps.Minutes))
+	}
+	// The quarter hour ending at 08:15 holds 0.3 mm, 1.2 mm/h from 08:00.
+	first := ps.Minutes[0].Time.Unix()
+	for _, m := range ps.Minutes {
+		off := (m.Time.Unix() - first) / 60
+		want := 0.0
+		if off >= 15 && off < 30 {
+			want = 1.2
+		} else if off >= 30 && off < 45 {
+			want = 2.4
+		}
+		if m.MMPerHour != want {
+			t.Fatalf("minute %d: got %.2f mm/h want %.2f", off, m.MMPerHour, want)
+		}
+	}
+	if want := int64(1780268400 - 15*60); first != want {
+		t.Fatalf("series starts at %d, want %d", first, want)
+	}
+}
+
+// Without an OpenWeather key the forecast and rain endpoints are served by
+// Open-Meteo.
+func TestFetchForecast_KeylessUsesOpenMeteo(t *testing.T) {
+	t.Setenv("WEATHER_PROVIDER", "")
+	t.Setenv("OPENWEATHER_API_KEY", "")
+
+	srv, _ := fixtureServer(t, "openmeteo_forecast.json")
+	t.Setenv("OPENMETEO_FORECAST_BASE", srv.URL)
+	fc, err := FetchForecast(context.Background(), NewFetchController(), 35.8, 139.56, MetricUnits, "ja")
+	if err != nil || fc.Provider != WeatherOpenMeteo {
+		t.Fatalf("FetchForecast: provider %q, error %v", fc.Provider, err)
+	}
+
+	srv, _ = fixtureServer(t, "openmeteo_minutely.json")
+	t.Setenv("OPENMETEO_FORECAST_BASE", srv.URL)
+	f := NewFetchController()
+	f.now = func() time.Time { return time.Unix(1780268400, 0) }
+	rw, err := FetchRainWindow(context.Background(), f, 35.8, 139.56, 20*time.Minute)
+	if err != nil || rw.Provider != WeatherOpenMeteo || len(rw.Minutely) != 60 {
+		t.Fatalf("FetchRainWindow: provider %q, %d minutes, error %v", rw.Provider, len(rw.Minutely), err)
+	}
+	// Rain falls in the quarter hours ending 08:15 and 08:30.
+	if !rw.RainingNow || rw.RainStopsAt == nil || rw.RainStopsAt.Unix() != 1780268400+30*60 {
+		t.Fatalf("unexpected rain window: %+v", rw)
+	}
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unit systems, as in OpenWeather's units parameter.
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+	UnitsStandard = "standard"
+)
+
+// Temperature and wind speed units.
+const (
+	TempCelsius    = "celsius"
+	TempFahrenheit = "fahrenheit"
+	TempKelvin     = "kelvin"
+
+	WindMS  = "m/s"
+	WindKMH = "km/h"
+	WindMPH = "mph"
+)
+
+// Units says which units a WeatherDTO's display values are in.
+type Units struct {
+	System      string `json:"system"`
+	Temperature string `json:"temperature"`
+	WindSpeed   string `json:"windSpeed"`
+}
+
+// MetricUnits is the canonical unit system weather is fetched in.
+var MetricUnits = Units{System: UnitsMetric, Temperature: TempCelsius, WindSpeed: WindMS}
+
+// ParseUnits resolves a unit system (empty means metric) and an optional
+// wind speed unit overriding the system's: m/s for metric and standard,
+// mph for imperial.
+func ParseUnits(system, wind string) (Units, error) {
+	var u Units
+	switch strings.ToLower(system) {
+	case "", UnitsMetric:
+		u = MetricUnits
+	case UnitsImperial:
+		u = Units{System: UnitsImperial, Temperature: TempFahrenheit, WindSpeed: WindMPH}
+	case UnitsStandard:
+		u = Units{System: UnitsStandard, Temperature: TempKelvin, WindSpeed: WindMS}
+	default:
+		return Units{}, fmt.Errorf("units must be metric, imperial or standard")
+	}
+	switch strings.ToLower(wind) {
+	case "":
+	case "m/s", "ms":
+		u.WindSpeed = WindMS
+	case "km/h", "kmh":
+		u.WindSpeed = WindKMH
+	case "mph":
+		u.WindSpeed = WindMPH
+	default:
+		return Units{}, fmt.Errorf("wind must be ms, kmh or mph")
+	}
+	return u, nil
+}
+
+func (u Units) temperature(c float64) float64 {
+	switch u.Temperature {
+	case TempFahrenheit:
+		return c*9/5 + 32
+	case TempKelvin:
+		return c + 273.15
+	}
+	return c
+}
+
+func (u Units) windSpeed(ms float64) float64 {
+	switch u.WindSpeed {
+	case WindKMH:
+		return ms * 3.6
+	case WindMPH:
+		return ms / 0.44704
+	}
+	return ms
+}
+
+// In fills the display values from the canonical metric fields.
+func (wd WeatherDTO) In(u Units) WeatherDTO {
+	wd.Units = u
+	wd.Temperature = u.temperature(wd.TemperatureC)
+	wd.WindSpeed = u.windSpeed(wd.WindSpeedMS)
+	wd.WindGust = u.windSpeed(wd.WindGustMS)
+	return wd
+}
+
+// In fills the forecast's display values from the canonical metric fields.
+func (fc ForecastDTO) In(u Units) ForecastDTO {
+	fc.Units = u
+	hourly := make([]HourlyDTO, len(fc.Hourly))
+	for i, h := range fc.Hourly {
+		h.Temperature = u.temperature(h.TemperatureC)
+		h.FeelsLike = u.temperature(h.FeelsLikeC)
+		h.WindSpeed = u.windSpeed(h.WindSpeedMS)
+		h.WindGust = u.windSpeed(h.WindGustMS)
+		hourly[i] = h
+	}
+	daily := make([]DailyDTO, len(fc.Daily))
+	for i, d := range fc.Daily {
+		d.TempMin = u.temperature(d.TempMinC)
+		d.TempMax = u.temperature(d.TempMaxC)
+		d.WindSpeed = u.windSpeed(d.WindSpeedMS)
+		daily[i] = d
+	}
+	fc.Hourly, fc.Daily = hourly, daily
+	return fc
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeatherDTO_In(t *testing.T) {
+	wd := WeatherDTO{TemperatureC: 20, WindSpeedMS: 10}
+	for _, tc := range []struct {
+		system, wind    string
+		temp, windSpeed float64
+		tempUnit, wUnit string
+	}{
+		{"", "", 20, 10, TempCelsius, WindMS},
+		{"imperial", "", 68, 22.369, TempFahrenheit, WindMPH},
+		{"standard", "", 293.15, 10, TempKelvin, WindMS},
+		{"metric", "kmh", 20, 36, TempCelsius, WindKMH},
+	} {
+		u, err := ParseUnits(tc.system, tc.wind)
+		if err != nil {
+			t.Fatalf("ParseUnits(%q, %q) error: %v", tc.system, tc.wind, err)
+		}
+		got := wd.In(u)
+		if math.Abs(got.Temperature-tc.temp) > 0.01 || math.Abs(got.WindSpeed-tc.windSpeed) > 0.01 ||
+			got.Units.Temperature != tc.tempUnit || got.Units.WindSpeed != tc.wUnit {
+			t.Errorf("%s/%s: unexpected display values %+v", tc.system, tc.wind, got)
+		}
+		// The canonical fields stay metric.
+		if got.TemperatureC != 20 || got.WindSpeedMS != 10 {
+			t.Errorf("%s/%s: canonical fields changed: %+v", tc.system, tc.wind, got)
+		}
+	}
+	for _, bad := range [][2]string{{"kelvin", ""}, {"metric", "knots"}} {
+		if _, err := ParseUnits(bad[0], bad[1]); err == nil {
+			t.Errorf("ParseUnits(%q, %q): expected an error", bad[0], bad[1])
+		}
+	}
+}
+
+func TestForecastDTO_In(t *testing.T) {
+	fc := ForecastDTO{
+		Hourly: []HourlyDTO{{TemperatureC: 20, FeelsLikeC: 25, WindSpeedMS: 10, WindGustMS: 20}},
+		Daily:  []DailyDTO{{TempMinC: 10, TempMaxC: 30, WindSpeedMS: 5}},
+	}
+	u, _ := ParseUnits("imperial", "")
+	got := fc.In(u)
+	h, d := got.Hourly[0], got.Daily[0]
+	near := func(a, b float64) bool { return math.Abs(a-b) < 0.01 }
+	if got.Units != u || !near(h.Temperature, 68) || !near(h.FeelsLike, 77) || !near(h.WindSpeed, 22.369) || !near(h.WindGust, 44.739) {
+		t.Fatalf("unexpected hour: %+v", h)
+	}
+	if !near(d.TempMin, 50) || !near(d.TempMax, 86) || !near(d.WindSpeed, 11.185) {
+		t.Fatalf("unexpected day: %+v", d)
+	}
+	// The canonical fields stay metric, and the input is not modified.
+	if h.TemperatureC != 20 || d.TempMaxC != 30 || fc.Hourly[0].Temperature != 0 {
+		t.Fatalf("canonical fields changed: %+v", got)
+	}
+}
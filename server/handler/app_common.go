@@ -4,18 +4,100 @@ import (
     "encoding/json"
     "net/http"
     "strconv"
+    "time"
+
+    "optimal-rion/server/controller"
+    "optimal-rion/server/controller/bus"
+    "optimal-rion/server/controller/route"
 )
 
 // AppData aggregates all sections the app needs.
 type AppData struct {
     Title   string `json:"title"`
-    Weather interface{} `json:"weather"`
-    Cycle struct {
-        DepartureName          string `json:"departureName"`
-        DestinationName        string `json:"destinationName"`
-        AvailableAtDeparture   int    `json:"availableAtDeparture"`
-        AvailableAtDestination int    `json:"availableAtDestination"`
-    } `json:"cycle"`
+    Weather weatherSection `json:"weather"`
+    Cycle   cycleOnly      `json:"cycle"`
+    Bus     busSection     `json:"bus"`
+    // Difficulty is omitted when weather is unavailable.
+    Difficulty *controller.DifficultyDTO `json:"difficulty,omitempty"`
+}
+
+// Section statuses. Upstream failures never fail the whole response;
+// the affected section is marked instead.
+const (
+    statusOK          = "ok"
+    statusStale       = "stale"
+    statusUnavailable = "unavailable"
+)
+
+// sectionStatus tells clients whether a section's values can be trusted,
+// e.g. "0 bikes" versus "bike data unavailable".
+type sectionStatus struct {
+    Status     string     `json:"status"`
+    Error      string     `json:"error,omitempty"`
+    FetchedAt  *time.Time `json:"fetchedAt,omitempty"`
+    AgeSeconds int        `json:"ageSeconds"`
+}
+
+func statusOf(age controller.DataAge, err error) sectionStatus {
+    if err != nil {
+        return sectionStatus{Status: statusUnavailable, Error: err.Error()}
+    }
+    st := sectionStatus{Status: statusOK, AgeSeconds: age.AgeSeconds}
+    if !age.FetchedAt.IsZero() {
+        t := age.FetchedAt
+        st.FetchedAt = &t
+    }
+    if age.Stale {
+        st.Status = statusStale
+    }
+    return st
+}
+
+type weatherSection struct {
+    controller.WeatherDTO
+    Status sectionStatus `json:"status"`
+}
+
+// difficultyOf rates a leg in the fetched weather; nil when the weather
+// could not be fetched.
+func difficultyOf(leg route.Leg, w controller.WeatherDTO, err error) *controller.DifficultyDTO {
+    if err != nil {
+        return nil
+    }
+    return controller.CyclingDifficulty(w, leg.Bearing(), leg.DistanceKM(), leg.Climb)
+}
+
+// busSection lists the upcoming shuttle departures for one direction.
+type busSection struct {
+    From       string          `json:"from"`
+    To         string          `json:"to"`
+    DayType    bus.DayType     `json:"dayType"`
+    DayNote    string          `json:"dayNote,omitempty"`
+    Departures []bus.Departure `json:"departures"`
+}
+
+// defaultBusDepartures is how many upcoming buses a response lists.
+const defaultBusDepartures = 3
+
+func busInfo(tt *bus.Timetable, dir bus.Direction, now time.Time, n int) busSection {
+    from, to := tt.Stops(dir)
+    day, note := tt.DayType(now)
+    return busSection{From: from, To: to, DayType: day, DayNote: note, Departures: tt.NextDepartures(dir, now, n)}
+}
+
+// nextBusWait converts the first upcoming departure into recommendation
+// input. A first departure on a later day means today's buses are over.
+func nextBusWait(deps []bus.Departure, now time.Time) (wait *time.Duration, noMore bool) {
+    if len(deps) == 0 {
+        return nil, true
+    }
+    y1, m1, d1 := deps[0].Time.Date()
+    y2, m2, d2 := now.In(bus.JST).Date()
+    if y1 != y2 || m1 != m2 || d1 != d2 {
+        return nil, true
+    }
+    d := deps[0].Time.Sub(now)
+    return &d, false
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
@@ -24,11 +106,6 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
     _ = json.NewEncoder(w).Encode(v)
 }
 
-const (
-    defaultLat = 35.813583
-    defaultLon = 139.565710
-)
-
 func parseFloatParam(r *http.Request, key string, def float64) (float64, error) {
     s := r.URL.Query().Get(key)
     if s == "" {
@@ -36,3 +113,25 @@ func parseFloatParam(r *http.Request, key string, def float64) (float64, error)
     }
     return strconv.ParseFloat(s, 64)
 }
+
+func parseIntParam(r *http.Request, key string, def int) (int, error) {
+    s := r.URL.Query().Get(key)
+    if s == "" {
+        return def, nil
+    }
+    return strconv.Atoi(s)
+}
+
+func parseBoolParam(r *http.Request, key string, def bool) (bool, error) {
+    s := r.URL.Query().Get(key)
+    if s == "" {
+        return def, nil
+    }
+    return strconv.ParseBool(s)
+}
+
+// parseUnits reads the units (metric, imperial, standard) and wind (ms,
+// kmh, mph) parameters.
+func parseUnits(r *http.Request) (controller.Units, error) {
+    return controller.ParseUnits(r.URL.Query().Get("units"), r.URL.Query().Get("wind"))
+}
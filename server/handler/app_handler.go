@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/route"
+)
+
+// AppHandler returns all data the app needs for one direction of a route.
+func AppHandler(fetch *controller.FetchController, rt *route.Route, dir route.Direction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		leg, _ := rt.Leg(dir)
+
+		defLat, defLon := rt.WeatherPoint()
+		lat, err := parseFloatParam(r, "lat", defLat)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		lon, err := parseFloatParam(r, "lon", defLon)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		units, err := parseUnits(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		lang := r.URL.Query().Get("lang")
+
+		ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
+		defer cancel()
+
+		// Weather and Hello Cycling totals in parallel
+		in := controller.FetchAppInputs(ctx, fetch, rt.StationGroups(), lat, lon, units, lang)
+		if in.WeatherErr != nil {
+			log.Printf("[warn] weather error: %v", in.WeatherErr)
+		}
+		if in.BikeErr != nil {
+			log.Printf("[warn] bike totals error: %v", in.BikeErr)
+		}
+
+		var resp AppData
+		resp.Title = rt.Title
+		resp.Weather = weatherSection{WeatherDTO: in.Weather, Status: statusOf(in.Weather.DataAge, in.WeatherErr)}
+		resp.Cycle = newCycleOnly(leg, in.Bike, in.BikeErr)
+		resp.Bus = busInfo(rt.Timetable, leg.Bus, time.Now(), defaultBusDepartures)
+		resp.Difficulty = difficultyOf(leg, in.Weather, in.WeatherErr)
+
+		writeJSON(w, http.StatusOK, resp)
+		log.Printf("AppHandler %s/%s: served %+v", rt.ID, dir, resp)
+	}
+}
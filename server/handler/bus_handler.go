@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"optimal-rion/server/controller/route"
+)
+
+// maxBusDepartures caps the n query parameter on the bus endpoints.
+const maxBusDepartures = 20
+
+// BusHandler returns the upcoming shuttle departures for one direction of a route.
+func BusHandler(rt *route.Route, dir route.Direction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		leg, _ := rt.Leg(dir)
+
+		n, err := parseIntParam(r, "n", defaultBusDepartures)
+		if err != nil || n < 1 || n > maxBusDepartures {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "n must be between 1 and 20"})
+			return
+		}
+
+		resp := busInfo(rt.Timetable, leg.Bus, time.Now(), n)
+		writeJSON(w, http.StatusOK, resp)
+
+		log.Printf("BusHandler %s/%s: served %d departures", rt.ID, dir, len(resp.Departures))
+	}
+}
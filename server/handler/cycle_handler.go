@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/history"
+	"optimal-rion/server/controller/route"
+)
+
+type cycleOnly struct {
+	DepartureName          string `json:"departureName"`
+	DestinationName        string `json:"destinationName"`
+	AvailableAtDeparture   int    `json:"availableAtDeparture"`
+	AvailableAtDestination int    `json:"availableAtDestination"`
+	// Fallback counts cover the groups' secondary stations.
+	FallbackAtDeparture   int `json:"fallbackAtDeparture"`
+	FallbackAtDestination int `json:"fallbackAtDestination"`
+	// Per-station breakdowns, only filled when requested with ?stations=true.
+	StationsAtDeparture   []controller.StationDetail `json:"stationsAtDeparture,omitempty"`
+	StationsAtDestination []controller.StationDetail `json:"stationsAtDestination,omitempty"`
+	// ChargedAtDeparture counts rentable bikes with enough battery range
+	// (or none needed); ChargeUnknownAtDeparture those the operator's
+	// feeds could not classify.
+	ChargedAtDeparture       int `json:"chargedAtDeparture"`
+	ChargeUnknownAtDeparture int `json:"chargeUnknownAtDeparture"`
+	// DestinationForecast predicts returnable docks at the destination when
+	// a ride started now arrives. Only the cycle endpoints fill it, and
+	// only while history is being recorded.
+	DestinationForecast *history.Forecast `json:"destinationForecast,omitempty"`
+	// Operators breaks the primary counts down by bike-share operator.
+	Operators []cycleOperator `json:"operators,omitempty"`
+	// References of ports at either end whose counts are absent or out of date.
+	MissingStations []string      `json:"missingStations,omitempty"`
+	StaleStations   []string      `json:"staleStations,omitempty"`
+	Status          sectionStatus `json:"status"`
+}
+
+// cycleOperator is one operator's share of a cycleOnly.
+type cycleOperator struct {
+	ID                     string `json:"id"`
+	Name                   string `json:"name"`
+	AvailableAtDeparture   int    `json:"availableAtDeparture"`
+	AvailableAtDestination int    `json:"availableAtDestination"`
+	Error                  string `json:"error,omitempty"`
+}
+
+// newCycleOnly counts rentable bikes where the leg departs and returnable
+// docks where it arrives.
+func newCycleOnly(leg route.Leg, bike controller.BikeTotalsDTO, err error) cycleOnly {
+	dep, dest := bike.Group(leg.DepartureGroup), bike.Group(leg.ArrivalGroup)
+	c := cycleOnly{
+		DepartureName:            leg.Departure.Name,
+		DestinationName:          leg.Arrival.Name,
+		AvailableAtDeparture:     dep.Rentable,
+		AvailableAtDestination:   dest.Returnable,
+		ChargedAtDeparture:       dep.Charged,
+		ChargeUnknownAtDeparture: dep.ChargeUnknown,
+		Status:                   statusOf(bike.DataAge, err),
+	}
+	for _, o := range bike.Operators {
+		co := cycleOperator{
+			ID:                     o.ID,
+			Name:                   o.Name,
+			AvailableAtDeparture:   dep.Operators[o.ID].Rentable,
+			AvailableAtDestination: dest.Operators[o.ID].Returnable,
+		}
+		if o.Err != nil {
+			co.Error = o.Err.Error()
+		}
+		c.Operators = append(c.Operators, co)
+	}
+	flag := func(missing, stale []string) {
+		c.MissingStations = append(c.MissingStations, missing...)
+		c.StaleStations = append(c.StaleStations, stale...)
+	}
+	flag(dep.MissingStations, dep.StaleStations)
+	flag(dest.MissingStations, dest.StaleStations)
+	if dep.Fallback != nil {
+		c.FallbackAtDeparture = dep.Fallback.Rentable
+		flag(dep.Fallback.MissingStations, dep.Fallback.StaleStations)
+	}
+	if dest.Fallback != nil {
+		c.FallbackAtDestination = dest.Fallback.Returnable
+		flag(dest.Fallback.MissingStations, dest.Fallback.StaleStations)
+	}
+	return c
+}
+
+// groupStations lists a group's primary ports followed by its secondary ones.
+func groupStations(bike controller.BikeTotalsDTO, group string) []controller.StationDetail {
+	g := bike.Group(group)
+	out := append([]controller.StationDetail(nil), g.Stations...)
+	if g.Fallback != nil {
+		out = append(out, g.Fallback.Stations...)
+	}
+	return out
+}
+
+// forecastArrival predicts returnable docks at the leg's arrival group,
+// comparing against past days with the same bus day type. A day whose type
+// doesn't resolve falls back to the Forecaster's weekday/weekend classes.
+func forecastArrival(hist *history.Store, rt *route.Route, leg route.Leg, current int, now time.Time) *history.Forecast {
+	f := &history.Forecaster{
+		Store: hist,
+		DayClass: func(t time.Time) string {
+			d, _ := rt.Timetable.DayType(t) // the second value is a display note
+			return string(d)
+		},
+	}
+	fc := f.Returnable(history.GroupKey(rt.ID, leg.ArrivalGroup), now, current, now.Add(rt.RideTime()))
+	return &fc
+}
+
+// CycleHandler returns only the rental cycle information for one direction
+// of a route. hist may be nil when history recording is disabled.
+func CycleHandler(fetch *controller.FetchController, rt *route.Route, dir route.Direction, hist *history.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		leg, _ := rt.Leg(dir)
+
+		withStations, err := parseBoolParam(r, "stations", false)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "stations must be a boolean"})
+			return
+		}
+
+		// Optional lat/lon not used here; GBFS is global
+		ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
+		defer cancel()
+
+		bike, err := controller.FetchBikeTotals(ctx, fetch, rt.StationGroups())
+		if err != nil {
+			log.Printf("[warn] cycle %s/%s error: %v", rt.ID, dir, err)
+		}
+
+		resp := newCycleOnly(leg, bike, err)
+		if hist != nil && err == nil {
+			resp.DestinationForecast = forecastArrival(hist, rt, leg, resp.AvailableAtDestination, time.Now())
+		}
+		if withStations {
+			resp.StationsAtDeparture = groupStations(bike, leg.DepartureGroup)
+			resp.StationsAtDestination = groupStations(bike, leg.ArrivalGroup)
+		}
+		writeJSON(w, http.StatusOK, resp)
+
+		log.Printf("CycleHandler %s/%s: served %+v", rt.ID, dir, resp)
+	}
+}
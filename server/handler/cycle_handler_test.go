@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/gbfs/gbfstest"
+	"optimal-rion/server/controller/history"
+	"optimal-rion/server/controller/route"
+)
+
+func TestCycleHandler_DestinationForecast(t *testing.T) {
+	srv := gbfstest.NewServer(t, `{"ttl": 60, "data": {"stations": [
+		{"station_id": "1", "num_bikes_available": 5, "num_docks_available": 1},
+		{"station_id": "2", "num_bikes_available": 2, "num_docks_available": 6}
+	]}}`)
+	t.Setenv("BIKE_GBFS_URL", srv.URL+"/gbfs.json")
+
+	groups := controller.StationGroups{
+		controller.GroupStation: {Primary: []string{"1"}},
+		controller.GroupCampus:  {Primary: []string{"2"}},
+	}
+	rt := route.Default(groups, nil).Default()
+
+	now := time.Now()
+	store, err := history.Open(t.TempDir(), 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	key := history.GroupKey(rt.ID, controller.GroupCampus)
+	if err := store.Append(history.Snapshot{Time: now.Add(-20 * time.Minute), Groups: map[string]history.Counts{key: {Returnable: 8}}}); err != nil {
+		t.Fatalf("Append error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	CycleHandler(controller.NewFetchController(), rt, route.ToSchool, store)(rec, httptest.NewRequest(http.MethodGet, "/api/cycle/to-school", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		AvailableAtDestination int               `json:"availableAtDestination"`
+		DestinationForecast    *history.Forecast `json:"destinationForecast"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	f := resp.DestinationForecast
+	if f == nil {
+		t.Fatalf("expected destinationForecast in %s", rec.Body)
+	}
+	// Docks have been filling up: the trend predicts fewer at arrival.
+	if resp.AvailableAtDestination != 6 || f.Basis != history.BasisTrend || f.Expected >= 6 {
+		t.Fatalf("unexpected forecast %+v for %d docks now", f, resp.AvailableAtDestination)
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/history"
+	"optimal-rion/server/controller/route"
+)
+
+type historyResponse struct {
+	Route    string                     `json:"route"`
+	From     time.Time                  `json:"from"`
+	To       time.Time                  `json:"to"`
+	Groups   map[string][]history.Point `json:"groups"`
+	Stations map[string][]history.Point `json:"stations"`
+}
+
+// maxHistoryHours caps the hours query parameter.
+const maxHistoryHours = 24 * 31
+
+// HistoryHandler handles GET /api/cycle/history and returns recorded bike
+// availability per group and station. Query parameters: route (default
+// route when empty), group and station to narrow the result, and hours to
+// look back (default 24). hist is nil when recording is disabled.
+func HistoryHandler(hist *history.Store, reg *route.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if hist == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "history recording is disabled; set HISTORY_DIR"})
+			return
+		}
+
+		q := r.URL.Query()
+		rt := reg.Default()
+		if id := q.Get("route"); id != "" {
+			var ok bool
+			if rt, ok = reg.Get(id); !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown route " + id})
+				return
+			}
+		}
+		hours, err := parseIntParam(r, "hours", 24)
+		if err != nil || hours < 1 || hours > maxHistoryHours {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "hours must be between 1 and 744"})
+			return
+		}
+
+		groups := rt.StationGroups()
+		if g := q.Get("group"); g != "" {
+			sg, ok := groups[g]
+			if !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown group " + g})
+				return
+			}
+			groups = map[string]controller.StationGroup{g: sg}
+		}
+
+		to := time.Now()
+		resp := historyResponse{
+			Route:    rt.ID,
+			From:     to.Add(-time.Duration(hours) * time.Hour),
+			To:       to,
+			Groups:   map[string][]history.Point{},
+			Stations: map[string][]history.Point{},
+		}
+		for name, g := range groups {
+			resp.Groups[name] = hist.GroupSeries(history.GroupKey(rt.ID, name), resp.From, to)
+			for _, refs := range [][]string{g.Primary, g.Secondary} {
+				for _, ref := range refs {
+					resp.Stations[history.StationKey(ref)] = nil
+				}
+			}
+		}
+		if st := q.Get("station"); st != "" {
+			key := history.StationKey(st)
+			if _, ok := resp.Stations[key]; !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "station " + st + " is not on this route"})
+				return
+			}
+			resp.Stations = map[string][]history.Point{key: nil}
+		}
+		for key := range resp.Stations {
+			resp.Stations[key] = hist.StationSeries(key, resp.From, to)
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
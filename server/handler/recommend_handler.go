@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/route"
+)
+
+type recommendResponse struct {
+	DepartureName   string                    `json:"departureName"`
+	DestinationName string                    `json:"destinationName"`
+	Recommendation  controller.Recommendation `json:"recommendation"`
+	Weather         weatherSection            `json:"weather"`
+	Cycle           cycleOnly                 `json:"cycle"`
+	Bus             busSection                `json:"bus"`
+	Difficulty      *controller.DifficultyDTO `json:"difficulty,omitempty"`
+}
+
+// RecommendHandler returns the bike-or-bus recommendation for one direction of a route.
+func RecommendHandler(fetch *controller.FetchController, rt *route.Route, dir route.Direction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		leg, _ := rt.Leg(dir)
+
+		defLat, defLon := rt.WeatherPoint()
+		lat, err := parseFloatParam(r, "lat", defLat)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		lon, err := parseFloatParam(r, "lon", defLon)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
+		defer cancel()
+
+		// The engine's thresholds are in metric units.
+		in := controller.FetchAppInputs(ctx, fetch, rt.StationGroups(), lat, lon, controller.MetricUnits, "")
+		if in.WeatherErr != nil {
+			log.Printf("[warn] weather error: %v", in.WeatherErr)
+		}
+		if in.BikeErr != nil {
+			log.Printf("[warn] bike totals error: %v", in.BikeErr)
+		}
+
+		now := time.Now()
+		resp := recommendResponse{
+			DepartureName:   leg.Departure.Name,
+			DestinationName: leg.Arrival.Name,
+			Weather:         weatherSection{WeatherDTO: in.Weather, Status: statusOf(in.Weather.DataAge, in.WeatherErr)},
+			Bus:             busInfo(rt.Timetable, leg.Bus, now, defaultBusDepartures),
+			Cycle:           newCycleOnly(leg, in.Bike, in.BikeErr),
+			Difficulty:      difficultyOf(leg, in.Weather, in.WeatherErr),
+		}
+		wait, noMore := nextBusWait(resp.Bus.Departures, now)
+		resp.Recommendation = controller.Recommend(controller.RecommendInput{
+			Weather:                in.Weather,
+			AvailableAtDeparture:   resp.Cycle.AvailableAtDeparture,
+			AvailableAtDestination: resp.Cycle.AvailableAtDestination,
+			FallbackAtDeparture:    resp.Cycle.FallbackAtDeparture,
+			FallbackAtDestination:  resp.Cycle.FallbackAtDestination,
+			NextBusIn:              wait,
+			NoMoreBuses:            noMore,
+			Difficulty:             resp.Difficulty,
+			WeatherUnavailable:     in.WeatherErr != nil,
+			BikesUnavailable:       in.BikeErr != nil,
+		})
+
+		writeJSON(w, http.StatusOK, resp)
+		log.Printf("RecommendHandler %s/%s: served %+v", rt.ID, dir, resp.Recommendation)
+	}
+}
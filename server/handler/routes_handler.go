@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/history"
+	"optimal-rion/server/controller/route"
+)
+
+type endpointSummary struct {
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+type routeSummary struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Origin      endpointSummary `json:"origin"`
+	Destination endpointSummary `json:"destination"`
+	Default     bool            `json:"default,omitempty"`
+}
+
+func summarize(e route.Endpoint) endpointSummary {
+	return endpointSummary{Name: e.Name, Lat: e.Lat, Lon: e.Lon}
+}
+
+// RoutesHandler serves the route registry:
+//
+//	GET /api/routes                                  list routes
+//	GET /api/routes/{id}/{direction}                 app data (like /api/app/*)
+//	GET /api/routes/{id}/{direction}/{cycle,bus,recommend}
+//
+// where direction is to-school or to-home.
+func RoutesHandler(fetch *controller.FetchController, reg *route.Registry, hist *history.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/routes"), "/")
+		if path == "" {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			def := reg.Default()
+			var out []routeSummary
+			for _, rt := range reg.All() {
+				out = append(out, routeSummary{
+					ID:          rt.ID,
+					Title:       rt.Title,
+					Origin:      summarize(rt.Origin),
+					Destination: summarize(rt.Destination),
+					Default:     rt == def,
+				})
+			}
+			writeJSON(w, http.StatusOK, out)
+			return
+		}
+
+		parts := strings.Split(path, "/")
+		if len(parts) < 2 || len(parts) > 3 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "expected /api/routes/{id}/{direction}"})
+			return
+		}
+		rt, ok := reg.Get(parts[0])
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown route " + parts[0]})
+			return
+		}
+		dir := route.Direction(parts[1])
+		if _, ok := rt.Leg(dir); !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown direction " + parts[1]})
+			return
+		}
+
+		section := ""
+		if len(parts) == 3 {
+			section = parts[2]
+		}
+		switch section {
+		case "":
+			AppHandler(fetch, rt, dir)(w, r)
+		case "cycle":
+			CycleHandler(fetch, rt, dir, hist)(w, r)
+		case "bus":
+			BusHandler(rt, dir)(w, r)
+		case "recommend":
+			RecommendHandler(fetch, rt, dir)(w, r)
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown section " + section})
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+
+	"optimal-rion/server/controller"
+)
+
+type statusResponse struct {
+	Breakers         []controller.BreakerStatus         `json:"breakers"`
+	WeatherProviders []controller.WeatherProviderStatus `json:"weatherProviders"`
+}
+
+// StatusHandler handles GET /api/status and reports upstream health.
+func StatusHandler(fetch *controller.FetchController) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, statusResponse{
+			Breakers:         fetch.BreakerStates(),
+			WeatherProviders: fetch.WeatherProviderStates(),
+		})
+	}
+}
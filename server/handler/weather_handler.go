@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/route"
+)
+
+type forecastResponse struct {
+	controller.ForecastDTO
+	Status sectionStatus `json:"status"`
+}
+
+// WeatherForecastHandler handles GET /api/weather/forecast: the next hours,
+// the daily summary, sunrise/sunset and active alerts. lat/lon default to
+// the route's weather point. units (metric, imperial, standard) and wind
+// (ms, kmh, mph) pick the display units; the suffixed fields stay metric.
+func WeatherForecastHandler(fetch *controller.FetchController, rt *route.Route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		defLat, defLon := rt.WeatherPoint()
+		lat, err := parseFloatParam(r, "lat", defLat)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		lon, err := parseFloatParam(r, "lon", defLon)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		units, err := parseUnits(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		lang := r.URL.Query().Get("lang")
+
+		ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
+		defer cancel()
+
+		fc, err := controller.FetchForecast(ctx, fetch, lat, lon, units, lang)
+		if err != nil {
+			log.Printf("[warn] forecast error: %v", err)
+		}
+		resp := forecastResponse{ForecastDTO: fc, Status: statusOf(fc.DataAge, err)}
+		writeJSON(w, http.StatusOK, resp)
+
+		log.Printf("WeatherForecastHandler: served %d hours, %d days, %d alerts", len(fc.Hourly), len(fc.Daily), len(fc.Alerts))
+	}
+}
+
+type rainResponse struct {
+	controller.RainWindowDTO
+	Status sectionStatus `json:"status"`
+}
+
+// maxRideMinutes caps the ride query parameter; the minutely forecast
+// only reaches an hour ahead.
+const maxRideMinutes = 60
+
+// WeatherRainHandler handles GET /api/weather/rain: the minutely
+// precipitation series with when rain starts and stops, what a ride
+// leaving now would get, and a dry departure window. ride is the ride
+// length in minutes and defaults to the route's ride time.
+func WeatherRainHandler(fetch *controller.FetchController, rt *route.Route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		defLat, defLon := rt.WeatherPoint()
+		lat, err := parseFloatParam(r, "lat", defLat)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		lon, err := parseFloatParam(r, "lon", defLon)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		ride := rt.RideTime()
+		if r.URL.Query().Has("ride") {
+			mins, err := parseIntParam(r, "ride", 0)
+			if err != nil || mins < 1 || mins > maxRideMinutes {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ride must be between 1 and 60"})
+				return
+			}
+			ride = time.Duration(mins) * time.Minute
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
+		defer cancel()
+
+		rw, err := controller.FetchRainWindow(ctx, fetch, lat, lon, ride)
+		if err != nil {
+			log.Printf("[warn] rain window error: %v", err)
+		}
+		resp := rainResponse{RainWindowDTO: rw, Status: statusOf(rw.DataAge, err)}
+		writeJSON(w, http.StatusOK, resp)
+
+		log.Printf("WeatherRainHandler: served %d minutes, ride %d min", len(rw.Minutely), rw.RideMinutes)
+	}
+}
+
+type difficultyResponse struct {
+	ToSchool *controller.DifficultyDTO `json:"toSchool,omitempty"`
+	ToHome   *controller.DifficultyDTO `json:"toHome,omitempty"`
+	Wind     struct {
+		SpeedMS float64 `json:"speedMs"`
+		GustMS  float64 `json:"gustMs"`
+		Deg     int     `json:"deg"`
+	} `json:"wind"`
+	// Units is always metric, as the field names say.
+	Units  controller.Units `json:"units"`
+	Status sectionStatus    `json:"status"`
+}
+
+// CycleDifficultyHandler handles GET /api/cycle/difficulty: how hard the
+// ride is in each direction given the current wind and the route's climb.
+// Values are metric only; other units or wind parameters are rejected.
+func CycleDifficultyHandler(fetch *controller.FetchController, rt *route.Route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if units, err := parseUnits(r); err != nil || units != controller.MetricUnits {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "difficulty is reported in metric units only"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 8*time.Second)
+		defer cancel()
+
+		lat, lon := rt.WeatherPoint()
+		wd, err := controller.FetchWeather(ctx, fetch, lat, lon, controller.MetricUnits, "")
+		if err != nil {
+			log.Printf("[warn] weather error: %v", err)
+		}
+		resp := difficultyResponse{Units: controller.MetricUnits, Status: statusOf(wd.DataAge, err)}
+		resp.Wind.SpeedMS, resp.Wind.GustMS, resp.Wind.Deg = wd.WindSpeedMS, wd.WindGustMS, wd.WindDeg
+		toSchool, _ := rt.Leg(route.ToSchool)
+		toHome, _ := rt.Leg(route.ToHome)
+		resp.ToSchool = difficultyOf(toSchool, wd, err)
+		resp.ToHome = difficultyOf(toHome, wd, err)
+		writeJSON(w, http.StatusOK, resp)
+
+		log.Printf("CycleDifficultyHandler %s: served %+v %+v", rt.ID, resp.ToSchool, resp.ToHome)
+	}
+}
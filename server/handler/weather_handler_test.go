@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/route"
+)
+
+func TestWeatherForecastHandler_Units(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"timezone": "Asia/Tokyo", "current": {"time": 1780268400},
+			"hourly": {"time": [1780268400], "temperature_2m": [20], "apparent_temperature": [25], "wind_speed_10m": [10], "wind_gusts_10m": [20]},
+			"daily": {"time": [1780239600], "temperature_2m_min": [10], "temperature_2m_max": [30], "wind_speed_10m_max": [5]}}`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("WEATHER_PROVIDER", controller.WeatherOpenMeteo)
+	t.Setenv("OPENMETEO_FORECAST_BASE", srv.URL)
+
+	h := WeatherForecastHandler(controller.NewFetchController(), route.Default(nil, nil).Default())
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/weather/forecast?units=imperial", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp forecastResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Units.Temperature != controller.TempFahrenheit || len(resp.Hourly) != 1 || len(resp.Daily) != 1 {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+	if hr := resp.Hourly[0]; math.Abs(hr.Temperature-68) > 0.01 || hr.TemperatureC != 20 || math.Abs(hr.WindSpeed-22.369) > 0.01 {
+		t.Fatalf("unexpected hour: %+v", hr)
+	}
+	if d := resp.Daily[0]; math.Abs(d.TempMax-86) > 0.01 {
+		t.Fatalf("unexpected day: %+v", d)
+	}
+
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/weather/forecast?units=kelvin", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad units, got %d", rec.Code)
+	}
+}
+
+func TestCycleDifficultyHandler_MetricOnly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"current": {"time": 1780268400, "temperature_2m": 20, "wind_speed_10m": 8, "wind_gusts_10m": 12, "wind_direction_10m": 0}}`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("WEATHER_PROVIDER", controller.WeatherOpenMeteo)
+	t.Setenv("OPENMETEO_FORECAST_BASE", srv.URL)
+
+	h := CycleDifficultyHandler(controller.NewFetchController(), route.Default(nil, nil).Default())
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/api/cycle/difficulty", nil))
+	var resp difficultyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d, decode error %v: %s", rec.Code, err, rec.Body)
+	}
+	if resp.Units != controller.MetricUnits || resp.Wind.SpeedMS != 8 || resp.ToSchool == nil || resp.ToSchool.HeadwindMS != 8 {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+
+	for _, q := range []string{"units=imperial", "wind=kmh", "units=bogus"} {
+		rec = httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, "/api/cycle/difficulty?"+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", q, rec.Code)
+		}
+	}
+}
@@ -4,21 +4,37 @@ import (
 	"net/http"
 
 	"optimal-rion/server/controller"
+	"optimal-rion/server/controller/history"
+	"optimal-rion/server/controller/route"
 	"optimal-rion/server/handler"
 )
 
 // Register wires up the HTTP routes.
-// It registers a single endpoint that returns all data needed by the app.
-func Register(mux *http.ServeMux, fetch *controller.FetchController) {
-	mux.HandleFunc("/api/app/to-school", handler.AppToSchoolHandler(fetch))
-	mux.HandleFunc("/api/app/to-home", handler.AppToHomeHandler(fetch))
-	mux.HandleFunc("/api/cycle/to-school", handler.CycleToSchoolHandler(fetch))
-	mux.HandleFunc("/api/cycle/to-home", handler.CycleToHomeHandler(fetch))
+// The /api/app, /api/cycle, /api/bus and /api/recommend endpoints serve the
+// registry's default route; /api/routes/ serves every route. hist may be
+// nil when history recording is disabled.
+func Register(mux *http.ServeMux, fetch *controller.FetchController, reg *route.Registry, hist *history.Store) {
+	def := reg.Default()
+	mux.HandleFunc("/api/app/to-school", handler.AppHandler(fetch, def, route.ToSchool))
+	mux.HandleFunc("/api/app/to-home", handler.AppHandler(fetch, def, route.ToHome))
+	mux.HandleFunc("/api/cycle/to-school", handler.CycleHandler(fetch, def, route.ToSchool, hist))
+	mux.HandleFunc("/api/cycle/to-home", handler.CycleHandler(fetch, def, route.ToHome, hist))
+	mux.HandleFunc("/api/cycle/history", handler.HistoryHandler(hist, reg))
+	mux.HandleFunc("/api/cycle/difficulty", handler.CycleDifficultyHandler(fetch, def))
+	mux.HandleFunc("/api/bus/to-school", handler.BusHandler(def, route.ToSchool))
+	mux.HandleFunc("/api/bus/to-home", handler.BusHandler(def, route.ToHome))
+	mux.HandleFunc("/api/recommend/to-school", handler.RecommendHandler(fetch, def, route.ToSchool))
+	mux.HandleFunc("/api/recommend/to-home", handler.RecommendHandler(fetch, def, route.ToHome))
+	mux.HandleFunc("/api/weather/forecast", handler.WeatherForecastHandler(fetch, def))
+	mux.HandleFunc("/api/weather/rain", handler.WeatherRainHandler(fetch, def))
+	mux.HandleFunc("/api/routes", handler.RoutesHandler(fetch, reg, hist))
+	mux.HandleFunc("/api/routes/", handler.RoutesHandler(fetch, reg, hist))
+	mux.HandleFunc("/api/status", handler.StatusHandler(fetch))
 }
 
 // New returns a pre-configured ServeMux with routes registered.
-func New(fetch *controller.FetchController) *http.ServeMux {
+func New(fetch *controller.FetchController, reg *route.Registry, hist *history.Store) *http.ServeMux {
 	mux := http.NewServeMux()
-	Register(mux, fetch)
+	Register(mux, fetch, reg, hist)
 	return mux
 }